@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/signing"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/urfave/cli"
+)
+
+var VerifyAttestationCommand = cli.Command{
+	Name:      "verify-attestation",
+	Action:    VerifyAttestation,
+	Usage:     "Verifies the Ethereum attestation of a billing JSON file",
+	ArgsUsage: "<billing json file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "signer",
+			Usage: "Published address of the attesting Ethereum account",
+		},
+	},
+}
+
+// attest signs the attestation message of the report and attaches the
+// attestation to the report.
+func attest(report *billing.Report, signer *signing.EthereumSigner) error {
+	message := report.AttestationMessage()
+
+	signature, err := signer.SignMessage([]byte(message))
+	if err != nil {
+		return fmt.Errorf("could not sign attestation message: [%v]", err)
+	}
+
+	report.Attestation = &billing.Attestation{
+		Message:   message,
+		Signer:    signer.Address().Hex(),
+		Signature: hexutil.Encode(signature),
+	}
+
+	return nil
+}
+
+func VerifyAttestation(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one billing json file to verify")
+	}
+
+	if !common.IsHexAddress(c.String("signer")) {
+		return fmt.Errorf("invalid signer address: [%v]", c.String("signer"))
+	}
+	expectedSigner := common.HexToAddress(c.String("signer"))
+
+	file := c.Args().First()
+
+	reportBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	report := &billing.BeaconReport{}
+	if err := json.Unmarshal(reportBytes, report); err != nil {
+		return fmt.Errorf("could not decode billing json: [%v]", err)
+	}
+
+	if report.Report == nil || report.Attestation == nil {
+		return fmt.Errorf("billing [%v] is not attested", file)
+	}
+
+	// the attested message has to reflect the figures of the report
+	if report.AttestationMessage() != report.Attestation.Message {
+		return fmt.Errorf(
+			"attested message does not match figures of billing [%v]",
+			file,
+		)
+	}
+
+	signature, err := hexutil.Decode(report.Attestation.Signature)
+	if err != nil {
+		return fmt.Errorf("could not decode attestation signature: [%v]", err)
+	}
+
+	signer, err := signing.RecoverMessageSigner(
+		[]byte(report.Attestation.Message),
+		signature,
+	)
+	if err != nil {
+		return err
+	}
+
+	if signer != expectedSigner {
+		return fmt.Errorf(
+			"billing [%v] is attested by [%v] instead of [%v]",
+			file,
+			signer.Hex(),
+			expectedSigner.Hex(),
+		)
+	}
+
+	logger.Infof("billing [%v] is attested by [%v]", file, signer.Hex())
+
+	return nil
+}
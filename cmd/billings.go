@@ -1,15 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/boar-network/keep-billings/pkg/assets"
 	"github.com/boar-network/keep-billings/pkg/billing"
 	"github.com/boar-network/keep-billings/pkg/chain"
 	"github.com/boar-network/keep-billings/pkg/exporter"
+	"github.com/boar-network/keep-billings/pkg/signing"
 	"github.com/ipfs/go-log"
 	"github.com/urfave/cli"
 )
@@ -18,8 +25,14 @@ var logger = log.Logger("billings-cmd")
 
 const (
 	defaultConfigFile = "./configs/config.toml"
+	configFlagUsage   = "Path to the TOML config file, " + defaultConfigFile +
+		" in the working or the executable directory if not set"
 )
 
+// beaconJsonFileNameFormat names billing JSON files read back by other
+// commands.
+const beaconJsonFileNameFormat = "%v_Beacon_Billing.json"
+
 var BillingsCommand = cli.Command{
 	Name:   "generate",
 	Action: GenerateBillings,
@@ -27,8 +40,30 @@ var BillingsCommand = cli.Command{
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  "config,c",
-			Value: defaultConfigFile,
-			Usage: "Path to the TOML config file",
+			Usage: configFlagUsage,
+		},
+		&cli.StringFlag{
+			Name:  "network,n",
+			Usage: "Name of the network profile, [Ethereum] config if not set",
+		},
+		&cli.Uint64Flag{
+			Name:  "start-block",
+			Usage: "First block of the reporting period",
+		},
+		&cli.Uint64Flag{
+			Name:  "end-block",
+			Usage: "Last block of the reporting period, current block if not set",
+		},
+		&cli.BoolFlag{
+			Name: "skip-existing",
+			Usage: "Generate only billings missing in the run directory; " +
+				"requires --end-block as the run directory is named " +
+				"after the period",
+		},
+		&cli.StringFlag{
+			Name:   "attestation-key-password",
+			EnvVar: "ATTESTATION_KEY_PASSWORD",
+			Usage:  "Password of the attestation keystore file",
 		},
 	},
 }
@@ -39,7 +74,10 @@ type Customers struct {
 }
 
 func GenerateBillings(c *cli.Context) error {
-	configPath := c.String("config")
+	configPath, err := resolveConfigPath(c.String("config"))
+	if err != nil {
+		return err
+	}
 
 	logger.Infof("generating billings using config [%v]", configPath)
 
@@ -48,43 +86,283 @@ func GenerateBillings(c *cli.Context) error {
 		return err
 	}
 
+	// without an end block each run covers a new period and goes to a new
+	// run directory, so there would be no billings to skip
+	if c.Bool("skip-existing") && !c.IsSet("end-block") {
+		return fmt.Errorf("--skip-existing requires --end-block")
+	}
+
 	customers, err := parseCustomers(config)
 	if err != nil {
 		return err
 	}
 
-	createTargetDirectory(config)
+	network, err := config.Network(c.String("network"))
+	if err != nil {
+		return err
+	}
 
-	ethereumClient, err := chain.NewEthereumClient(
-		config.Ethereum.URL,
-		config.Ethereum.KeepToken,
-		config.Ethereum.TokenStaking,
-		config.Ethereum.KeepRandomBeaconOperator,
+	ctx, cancel := runContext(config.Billings.RunTimeout.Duration)
+	defer cancel()
+
+	ethereumClient, err := newEthereumClient(ctx, config, network)
+	if err != nil {
+		return err
+	}
+
+	chainID, err := ethereumClient.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get chain ID: [%v]", err)
+	}
+
+	period := &billing.Period{
+		StartBlock: c.Uint64("start-block"),
+		EndBlock:   c.Uint64("end-block"),
+	}
+
+	beaconReportGenerator := billing.NewBeaconReportGenerator(
+		ethereumClient,
+		period,
+		network.KeepRewardDistributors,
+		rewardTokens(network),
+	)
+
+	beaconPdfExporters := newPdfExporters(
+		config,
+		assets.BeaconBillingTemplate,
+		beaconTemplateFile(config),
 	)
+
+	beaconPdfExporter, err := beaconPdfExporters.forCustomer(&billing.Customer{})
+	if err != nil {
+		return fmt.Errorf("could not create billing pdf exporter: [%v]", err)
+	}
+
+	customerPdfExporters, err := beaconPdfExporters.byCustomer(customers.Beacon)
 	if err != nil {
 		return err
 	}
 
-	beaconReportGenerator := billing.NewBeaconReportGenerator(ethereumClient)
+	beaconKeepLedgerExporter := exporter.NewCsvExporter(
+		func(data interface{}) ([][]string, error) {
+			report, ok := data.(*billing.BeaconReport)
+			if !ok {
+				return nil, fmt.Errorf("unexpected report type: [%T]", data)
+			}
 
-	beaconPdfExporter, err := exporter.NewPdfExporter(
-		config.Billings.BeaconTemplateFile,
+			return report.KeepLedgerRecords(), nil
+		},
 	)
+
+	var signer *signing.Signer
+	if len(config.Billings.SigningKeyFile) > 0 {
+		signer, err = signing.NewSigner(config.Billings.SigningKeyFile)
+		if err != nil {
+			return fmt.Errorf("could not read signing key: [%v]", err)
+		}
+	}
+
+	var attestationSigner *signing.EthereumSigner
+	if len(config.Billings.AttestationKeyFile) > 0 {
+		attestationSigner, err = signing.NewEthereumSigner(
+			config.Billings.AttestationKeyFile,
+			c.String("attestation-key-password"),
+		)
+		if err != nil {
+			return fmt.Errorf("could not read attestation key: [%v]", err)
+		}
+
+		logger.Infof(
+			"attesting billings with account [%v]",
+			attestationSigner.Address().Hex(),
+		)
+	}
+
+	currency := config.Prices.Currency
+	if len(currency) == 0 {
+		currency = defaultCurrency
+	}
+
+	beaconJournalOutputs, err := journalOutputs(config.Journal, currency)
 	if err != nil {
 		return err
 	}
 
+	var prices billing.PriceSource
+	if len(beaconJournalOutputs) > 0 {
+		prices, err = priceSource(config.Prices, currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	beaconJsonOutput := &output{
+		exporter:       exporter.NewJsonExporter(),
+		fileNameFormat: beaconJsonFileNameFormat,
+		description:    "billing json",
+	}
+
 	generateBillings(
+		ctx,
+		config.Billings.TargetDirectory,
+		blocksRunName(period),
+		chainID.Uint64(),
+		period,
+		c.Bool("skip-existing"),
 		customers.Beacon,
 		beaconReportGenerator.FetchCommonData,
-		func(customer *billing.Customer) (interface{}, error) {
-			return beaconReportGenerator.Generate(customer)
+		func(
+			ctx context.Context,
+			customer *billing.Customer,
+		) (interface{}, error) {
+			report, err := beaconReportGenerator.Generate(ctx, customer)
+			if err != nil {
+				return nil, err
+			}
+
+			if attestationSigner != nil {
+				if err := attest(report.Report, attestationSigner); err != nil {
+					return nil, err
+				}
+			}
+
+			if prices != nil {
+				report.Journal, err = billing.NewJournal(
+					ctx,
+					report,
+					journalAccounts(config.Journal.Accounts),
+					prices,
+				)
+				if err != nil {
+					return nil, fmt.Errorf("could not book journal: [%v]", err)
+				}
+			}
+
+			report.History = loadHistory(
+				config.Billings.TargetDirectory,
+				customer,
+				report,
+				beaconJsonOutput.fileName(customer),
+			)
+
+			return report, nil
 		},
-		beaconPdfExporter,
-		config.Billings.TargetDirectory+"/%v_Beacon_Billing.pdf",
+		append([]*output{
+			{
+				exporter:          beaconPdfExporter,
+				customerExporters: customerPdfExporters,
+				fileNameFormat:    "%v_Beacon_Billing.pdf",
+				description:       "billing pdf",
+			},
+			{
+				exporter:       beaconKeepLedgerExporter,
+				fileNameFormat: "%v_Beacon_KEEP_Ledger.csv",
+				description:    "KEEP ledger csv",
+			},
+			beaconJsonOutput,
+		}, beaconJournalOutputs...),
+		signer,
 	)
 
-	return nil
+	logEndpointsStats(ethereumClient.EndpointsStats())
+
+	return ctx.Err()
+}
+
+// runContext returns the context of the whole run. The context is cancelled
+// on the first interrupt signal or when the run timeout, if set, elapses.
+// The second interrupt signal terminates the process immediately.
+func runContext(runTimeout time.Duration) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if runTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), runTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			logger.Warnf(
+				"interrupted; stopping after cleaning up the current customer, " +
+					"interrupt again to terminate immediately",
+			)
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// newEthereumClient connects to the network, discovers operator contracts
+// and enables the log indexer as configured.
+func newEthereumClient(
+	ctx context.Context,
+	config *Config,
+	network *Ethereum,
+) (*chain.EthereumClient, error) {
+	ethereumClient, err := chain.NewEthereumClient(
+		ctx,
+		endpoints(network),
+		network.CallTimeout.Duration,
+		network.ChainID,
+		network.KeepToken,
+		network.TokenStaking,
+		network.KeepRandomBeaconOperator,
+		network.Confirmations,
+		config.Indexer.MaxChunkSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(network.Registry) > 0 {
+		err := ethereumClient.DiscoverOperatorContracts(
+			ctx,
+			network.Registry,
+			network.StartBlock,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(config.Indexer.StoreFile) > 0 {
+		err := ethereumClient.EnableLogIndexer(
+			indexerStoreFile(config.Indexer.StoreFile, network.ChainID),
+			network.StartBlock,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ethereumClient, nil
+}
+
+// indexerStoreFile keys the configured store file by the chain ID, so each
+// network keeps its own store and switching networks doesn't discard logs
+// synced for other ones. The file is used as is if the chain ID is not set.
+func indexerStoreFile(storeFile string, chainID uint64) string {
+	if chainID == 0 {
+		return storeFile
+	}
+
+	extension := filepath.Ext(storeFile)
+
+	return fmt.Sprintf(
+		"%v_%v%v",
+		strings.TrimSuffix(storeFile, extension),
+		chainID,
+		extension,
+	)
 }
 
 func parseCustomers(config *Config) (*Customers, error) {
@@ -98,70 +376,328 @@ func parseCustomers(config *Config) (*Customers, error) {
 		return nil, err
 	}
 
+	for _, group := range [][]billing.Customer{
+		customers.Beacon,
+		customers.Ecdsa,
+	} {
+		if err := verifyUniqueNames(group); err != nil {
+			return nil, err
+		}
+	}
+
+	// Template files of customers are relative to the customers file.
+	directory := filepath.Dir(config.Billings.CustomersFile)
+	for _, group := range [][]billing.Customer{
+		customers.Beacon,
+		customers.Ecdsa,
+	} {
+		for i := range group {
+			group[i].TemplateFile = resolvePath(
+				directory,
+				group[i].TemplateFile,
+			)
+		}
+	}
+
 	return &customers, nil
 }
 
-func createTargetDirectory(config *Config) {
-	if _, err := os.Stat(config.Billings.TargetDirectory); os.IsNotExist(err) {
-		_ = os.Mkdir(config.Billings.TargetDirectory, 0777)
+// verifyUniqueNames makes sure no two customers share a name. Names key
+// output files and manifest entries, so customers sharing a name, even if
+// differing only in case or in spaces replaced in file names, would
+// overwrite each other's billings.
+func verifyUniqueNames(customers []billing.Customer) error {
+	names := make(map[string]string)
+	for _, customer := range customers {
+		key := strings.ToLower(strings.ReplaceAll(customer.Name, " ", "_"))
+		if name, ok := names[key]; ok {
+			return fmt.Errorf(
+				"customers [%v] and [%v] have conflicting names",
+				name,
+				customer.Name,
+			)
+		}
+		names[key] = customer.Name
+	}
+
+	return nil
+}
+
+// pdfExporters creates PDF exporters presenting reports with the template,
+// the branding and the language of each customer. Customers presented the
+// same way share the exporter.
+type pdfExporters struct {
+	config *Config
+	// bundled template used if the customer's template file is not set
+	bundledTemplate string
+	templateFile    func(customer *billing.Customer) string
+	exporters       map[string]exporter.Exporter
+}
+
+func newPdfExporters(
+	config *Config,
+	bundledTemplate string,
+	templateFile func(customer *billing.Customer) string,
+) *pdfExporters {
+	return &pdfExporters{
+		config:          config,
+		bundledTemplate: bundledTemplate,
+		templateFile:    templateFile,
+		exporters:       make(map[string]exporter.Exporter),
+	}
+}
+
+// beaconTemplateFile returns the billing template of the customer, the
+// configured one if the customer has no template.
+func beaconTemplateFile(config *Config) func(customer *billing.Customer) string {
+	return func(customer *billing.Customer) string {
+		if len(customer.TemplateFile) > 0 {
+			return customer.TemplateFile
+		}
+
+		return config.Billings.BeaconTemplateFile
+	}
+}
+
+func (pe *pdfExporters) forCustomer(
+	customer *billing.Customer,
+) (exporter.Exporter, error) {
+	templateFile := pe.templateFile(customer)
+
+	language := pe.config.Billings.Language
+	if len(customer.Language) > 0 {
+		language = customer.Language
+	}
+	if len(language) == 0 {
+		language = exporter.DefaultLanguage
+	}
+
+	key := strings.Join([]string{templateFile, customer.Branding, language}, "|")
+	if pdfExporter, ok := pe.exporters[key]; ok {
+		return pdfExporter, nil
+	}
+
+	brandingConfig, err := pe.config.BrandingByName(customer.Branding)
+	if err != nil {
+		return nil, err
+	}
+
+	branding, err := exporter.NewBranding(
+		brandingConfig.Name,
+		brandingConfig.Website,
+		brandingConfig.Address,
+		brandingConfig.VatID,
+		brandingConfig.LogoFile,
+		brandingConfig.PrimaryColor,
+		brandingConfig.AccentColor,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := exporter.LoadCatalog(
+		pe.config.Billings.TranslationsDirectory,
+		language,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pdfExporter, err := exporter.NewPdfExporter(
+		templateFile,
+		pe.bundledTemplate,
+		pe.config.Billings.PartialsDirectory,
+		exporter.TemplateFuncs(
+			pe.config.Billings.BlockExplorerURL,
+			branding,
+			catalog,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pe.exporters[key] = pdfExporter
+
+	return pdfExporter, nil
+}
+
+// byCustomer returns exporters of all the customers, by customer name.
+func (pe *pdfExporters) byCustomer(
+	customers []billing.Customer,
+) (map[string]exporter.Exporter, error) {
+	exporters := make(map[string]exporter.Exporter)
+
+	for i := range customers {
+		pdfExporter, err := pe.forCustomer(&customers[i])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not create pdf exporter for customer [%v]: [%v]",
+				customers[i].Name,
+				err,
+			)
+		}
+
+		exporters[customers[i].Name] = pdfExporter
+	}
+
+	return exporters, nil
+}
+
+func endpoints(network *Ethereum) []chain.Endpoint {
+	endpoints := make([]chain.Endpoint, 0, len(network.Endpoints)+1)
+
+	if len(network.URL) > 0 {
+		endpoints = append(endpoints, chain.Endpoint{URL: network.URL})
+	}
+
+	for _, endpoint := range network.Endpoints {
+		endpoints = append(endpoints, chain.Endpoint{
+			URL:      endpoint.URL,
+			Priority: endpoint.Priority,
+		})
+	}
+
+	return endpoints
+}
+
+func logEndpointsStats(stats []*chain.EndpointStats) {
+	for _, endpointStats := range stats {
+		logger.Infof(
+			"endpoint [%v] with priority [%v] served [%v] calls, "+
+				"[%v] calls failed, healthy: [%v]",
+			endpointStats.URL,
+			endpointStats.Priority,
+			endpointStats.Served,
+			endpointStats.Failed,
+			endpointStats.Healthy,
+		)
+	}
+}
+
+func rewardTokens(network *Ethereum) []*billing.RewardToken {
+	rewardTokens := make([]*billing.RewardToken, len(network.RewardTokens))
+
+	for i, rewardToken := range network.RewardTokens {
+		rewardTokens[i] = &billing.RewardToken{
+			Address:      rewardToken.Address,
+			Distributors: rewardToken.Distributors,
+		}
+	}
+
+	return rewardTokens
+}
+
+// blocksRunName names the run directory of billings after the period.
+func blocksRunName(period *billing.Period) func() string {
+	return func() string {
+		return fmt.Sprintf("blocks_%v-%v", period.StartBlock, period.EndBlock)
 	}
 }
 
+// generateBillings generates outputs of all customers in the run directory
+// named by runName within the target directory. The run name is resolved
+// once the generator is set up.
 func generateBillings(
+	ctx context.Context,
+	targetDirectory string,
+	runName func() string,
+	chainID uint64,
+	period *billing.Period,
+	skipExisting bool,
 	customers []billing.Customer,
-	setUp func() error,
-	generate func(customer *billing.Customer) (interface{}, error),
-	pdfExporter *exporter.PdfExporter,
-	fileNameFormat string,
+	setUp func(ctx context.Context) error,
+	generate func(
+		ctx context.Context,
+		customer *billing.Customer,
+	) (interface{}, error),
+	outputs []*output,
+	signer *signing.Signer,
 ) {
 	if len(customers) == 0 {
 		logger.Infof("no customers to generate the report for, quitting")
 		return
 	}
 
-	if err := setUp(); err != nil {
+	if err := setUp(ctx); err != nil {
 		logger.Errorf("could not set up generator: [%v]", err)
 		return
 	}
 
-	for _, customer := range customers {
-		logger.Infof("generating billing for [%v]", customer.Name)
+	// the period is known only once the generator is set up
+	runDirectory := filepath.Join(targetDirectory, runName())
+	if err := os.MkdirAll(runDirectory, 0777); err != nil {
+		logger.Errorf("could not create run directory: [%v]", err)
+		return
+	}
 
-		report, err := generate(&customer)
-		if err != nil {
-			logger.Errorf(
-				"could not generate billing report for customer [%v]: [%v]",
+	logger.Infof("writing outputs to [%v]", runDirectory)
+
+	runManifest, err := loadManifest(runDirectory, chainID, period)
+	if err != nil {
+		logger.Errorf("could not load run manifest: [%v]", err)
+		return
+	}
+
+	for _, customer := range customers {
+		if ctx.Err() != nil {
+			logger.Warnf(
+				"billing run stopped before customer [%v]: [%v]",
 				customer.Name,
-				err,
+				ctx.Err(),
 			)
+			return
+		}
+
+		if skipExisting &&
+			runManifest.hasValidOutputs(runDirectory, &customer, outputs, signer) {
+			logger.Infof("skipping existing billing for [%v]", customer.Name)
 			continue
 		}
 
-		fileBytes, err := pdfExporter.Export(report)
+		logger.Infof("generating billing for [%v]", customer.Name)
+
+		report, err := generate(ctx, &customer)
 		if err != nil {
 			logger.Errorf(
-				"could not export billing pdf for customer [%v]: [%v]",
+				"could not generate billing report for customer [%v]: [%v]",
 				customer.Name,
 				err,
 			)
 			continue
 		}
 
-		fileName := fmt.Sprintf(
-			fileNameFormat,
-			strings.ReplaceAll(customer.Name, " ", "_"),
+		files, err := exportOutputs(
+			ctx,
+			runDirectory,
+			&customer,
+			report,
+			outputs,
+			signer,
 		)
-
-		err = ioutil.WriteFile(fileName, fileBytes, 0666)
 		if err != nil {
 			logger.Errorf(
-				"could not write billing pdf file for customer [%v]: [%v]",
+				"could not export billing for customer [%v]: [%v]",
 				customer.Name,
 				err,
 			)
+
+			// outputs may be partially replaced so none of them is valid
+			runManifest.update(customer.Name, nil)
+			if err := runManifest.save(runDirectory); err != nil {
+				logger.Errorf("could not save run manifest: [%v]", err)
+				return
+			}
+
 			continue
 		}
 
+		runManifest.update(customer.Name, files)
+		if err := runManifest.save(runDirectory); err != nil {
+			logger.Errorf("could not save run manifest: [%v]", err)
+			return
+		}
+
 		logger.Infof("completed billing for [%v]", customer.Name)
 	}
 }
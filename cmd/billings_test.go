@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/exporter"
+)
+
+func TestIndexerStoreFile(t *testing.T) {
+	var tests = map[string]struct {
+		storeFile string
+		chainID   uint64
+		expected  string
+	}{
+		"chain ID set": {
+			storeFile: "./index/logs.json",
+			chainID:   1,
+			expected:  "./index/logs_1.json",
+		},
+		"chain ID not set": {
+			storeFile: "./index/logs.json",
+			chainID:   0,
+			expected:  "./index/logs.json",
+		},
+		"no extension": {
+			storeFile: "./index/logs",
+			chainID:   3,
+			expected:  "./index/logs_3",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := indexerStoreFile(test.storeFile, test.chainID)
+			if actual != test.expected {
+				t.Errorf(
+					"unexpected store file\nexpected: [%v]\nactual:   [%v]",
+					test.expected,
+					actual,
+				)
+			}
+		})
+	}
+}
+
+func TestVerifyUniqueNames(t *testing.T) {
+	var tests = map[string]struct {
+		names         []string
+		expectedError bool
+	}{
+		"unique names": {
+			names: []string{"Alice", "Bob"},
+		},
+		"same names": {
+			names:         []string{"Alice", "Bob", "Alice"},
+			expectedError: true,
+		},
+		"names differing in case": {
+			names:         []string{"Alice", "alice"},
+			expectedError: true,
+		},
+		"names differing in spaces": {
+			names:         []string{"Acme Inc", "Acme_Inc"},
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			customers := make([]billing.Customer, len(test.names))
+			for i, name := range test.names {
+				customers[i] = billing.Customer{Name: name}
+			}
+
+			err := verifyUniqueNames(customers)
+			if test.expectedError && err == nil {
+				t.Error("expected error for conflicting names")
+			}
+			if !test.expectedError && err != nil {
+				t.Errorf("unexpected error: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestGenerateBillingsStopsOnCancellation(t *testing.T) {
+	directory, err := ioutil.TempDir("", "billings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	generated := make([]string, 0)
+
+	generateBillings(
+		ctx,
+		directory,
+		func() string { return "run" },
+		1,
+		&billing.Period{StartBlock: 100, EndBlock: 200},
+		false,
+		[]billing.Customer{{Name: "A"}, {Name: "B"}, {Name: "C"}},
+		func(ctx context.Context) error { return nil },
+		func(
+			ctx context.Context,
+			customer *billing.Customer,
+		) (interface{}, error) {
+			generated = append(generated, customer.Name)
+			// the run is interrupted while generating the first billing
+			cancel()
+			return map[string]string{"customer": customer.Name}, nil
+		},
+		[]*output{{
+			exporter:       exporter.NewJsonExporter(),
+			fileNameFormat: "%v.json",
+			description:    "billing JSON",
+		}},
+		nil,
+	)
+
+	if !reflect.DeepEqual(generated, []string{"A"}) {
+		t.Errorf(
+			"unexpected generated billings\nexpected: [[A]]\nactual:   [%v]",
+			generated,
+		)
+	}
+
+	runManifest, err := loadManifest(
+		filepath.Join(directory, "run"),
+		1,
+		&billing.Period{StartBlock: 100, EndBlock: 200},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(runManifest.Files) != 0 {
+		t.Errorf("unexpected files in manifest: [%v]", runManifest.Files)
+	}
+
+	if _, err := os.Stat(filepath.Join(directory, "run", "A.json")); err == nil {
+		t.Error("unexpected output of the interrupted billing")
+	}
+}
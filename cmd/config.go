@@ -2,32 +2,277 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
 type Config struct {
 	Billings Billings
+	// default network used when no network profile is selected
 	Ethereum Ethereum
+	// named network profiles, selected with the network flag
+	Networks map[string]Ethereum
+	Indexer  Indexer
+	Email    Email
+	Prices   Prices
+	Journal  Journal
+	// default branding of billings
+	Branding Branding
+	// named brandings, selected per customer
+	Brandings map[string]Branding
 }
 
 type Billings struct {
-	CustomersFile      string
-	TargetDirectory    string
+	CustomersFile   string
+	TargetDirectory string
+	// the bundled default template is used if not set
 	BeaconTemplateFile string
+	// template of annual statements; the bundled default template is used
+	// if not set
+	AnnualStatementTemplateFile string
+	// directory with HTML files defining named templates, like a header or
+	// a footer, overriding the bundled ones; optional
+	PartialsDirectory string
+	// block explorer linked from billing templates, Etherscan if not set
+	BlockExplorerURL string
+	// directory with translation catalogs of billing labels, one
+	// `<language>.json` file per language, taking precedence over the
+	// bundled catalogs; optional
+	TranslationsDirectory string
+	// language of billings, English if not set
+	Language string
+	// time limit of the whole run; not limited if not set
+	RunTimeout Duration
+	// PEM file with the Ed25519 private key signing generated files;
+	// files are not signed if not set
+	SigningKeyFile string
+	// Ethereum keystore file of the account attesting report figures;
+	// reports are not attested if not set; its password is read from the
+	// ATTESTATION_KEY_PASSWORD environment variable
+	AttestationKeyFile string
 }
 
 type Ethereum struct {
-	URL                      string
+	// single endpoint shorthand, used along with Endpoints if both are set
+	URL string
+	// endpoints tried in order of priority when calls fail or time out
+	Endpoints []Endpoint
+	// time limit of a single call made against a single endpoint
+	CallTimeout Duration
+
 	KeepToken                string
 	TokenStaking             string
 	KeepRandomBeaconOperator string
+	// KEEP registry used to discover all beacon operator contracts,
+	// including the ones replaced by newer versions; optional
+	Registry string
+
+	// expected chain ID of the node; not verified if not set
+	ChainID uint64
+
+	// first block scanned for events, typically the block the oldest of the
+	// contracts, including the Registry, was deployed at; the log indexer
+	// and the operator contracts discovery start there
+	StartBlock uint64
+
+	// number of blocks mined on top of a block after which the block is
+	// considered final; reports never cover blocks not confirmed yet
+	Confirmations uint64
+
+	// addresses whose KEEP transfers to beneficiaries are staking rewards
+	KeepRewardDistributors []string
+
+	RewardTokens []RewardToken
+}
+
+type Endpoint struct {
+	URL string
+	// endpoints with higher priority are tried first
+	Priority int
+}
+
+type RewardToken struct {
+	Address string
+	// addresses whose token transfers to beneficiaries are staking rewards
+	Distributors []string
+}
+
+// Indexer configures the local store of staking and operator contract
+// events. The indexer is disabled if the store file is not set.
+type Indexer struct {
+	// suffixed with the chain ID of the network, if set, so each network
+	// has its own store, e.g. logs_1.json
+	StoreFile string
+	// deprecated, used as the StartBlock of the default [Ethereum] network
+	// if it's not set there; start blocks differ between networks so they
+	// are set per network
+	StartBlock uint64
+	// maximum number of blocks fetched in a single query
+	MaxChunkSize uint64
+}
+
+// Branding presents the provider issuing billings. The logo is embedded in
+// billings; colors are hex or named CSS colors.
+type Branding struct {
+	Name         string
+	Website      string
+	Address      string
+	VatID        string
+	LogoFile     string
+	PrimaryColor string
+	AccentColor  string
+}
+
+// Email configures delivery of generated billings to customers.
+type Email struct {
+	SmtpHost string
+	SmtpPort int
+	// SMTP credentials; messages are sent without authentication if the
+	// username is not set
+	SmtpUsername string
+	SmtpPassword string
+
+	From string
+	// text/template of the subject and file with the text/template of the
+	// body; both are executed with the customer and the reporting period;
+	// bundled defaults are used if not set
+	Subject          string
+	BodyTemplateFile string
+}
+
+// Prices configures the source of historical token prices valuing rewards
+// in annual statements.
+type Prices struct {
+	// fiat currency of prices, for example USD or EUR
+	Currency string
+	// CSV file with date, token symbol and price records; prices are read
+	// from CoinGecko if not set
+	File string
+	// CoinGecko API URL, the public API if not set
+	CoinGeckoURL string
+	// CoinGecko coin IDs by token symbol, complementing the ETH and KEEP
+	// coin IDs known by default
+	CoinIDs map[string]string
+}
+
+// Journal configures accounting journal exports of billings. Journal
+// values use the [Prices] currency and prices.
+type Journal struct {
+	// export formats: "csv" for the generic journal, "xero" for the Xero
+	// manual journal import and "quickbooks" for the QuickBooks IIF import;
+	// journals are not exported if not set
+	Formats []string
+	// Xero tax rate of journal lines, "Tax Exempt" if not set
+	XeroTaxRate string
+	// chart of accounts; defaults are used for accounts not set
+	Accounts JournalAccounts
+}
+
+// JournalAccounts are account codes or names journal lines are posted to.
+type JournalAccounts struct {
+	RewardsClearing string
+	ProviderRevenue string
+	CustomerPayable string
+	GasExpense      string
+	OperatorWallet  string
+}
+
+// Duration is a time duration read from a TOML string like "30s" or "5m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	d.Duration = duration
+	return nil
+}
+
+// Network returns the network profile with the given name or the default
+// network if the name is empty. Named profiles have to declare the chain ID
+// so connecting to the wrong chain is never possible.
+func (c *Config) Network(name string) (*Ethereum, error) {
+	if len(name) == 0 {
+		return &c.Ethereum, nil
+	}
+
+	network, ok := c.Networks[name]
+	if !ok {
+		names := make([]string, 0, len(c.Networks))
+		for networkName := range c.Networks {
+			names = append(names, networkName)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf(
+			"unknown network [%v]; configured networks: %v",
+			name,
+			names,
+		)
+	}
+
+	if network.ChainID == 0 {
+		return nil, fmt.Errorf("chain ID of network [%v] is not set", name)
+	}
+
+	return &network, nil
+}
+
+// BrandingByName returns the named branding or the default one if the name
+// is empty.
+func (c *Config) BrandingByName(name string) (*Branding, error) {
+	if len(name) == 0 {
+		return &c.Branding, nil
+	}
+
+	branding, ok := c.Brandings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown branding [%v]", name)
+	}
+
+	return &branding, nil
+}
+
+// resolveConfigPath returns the given config path or, if not set, the path
+// of the default config file found in the working directory or, so the tool
+// can be run from anywhere, in the directory of the executable.
+func resolveConfigPath(configPath string) (string, error) {
+	if len(configPath) > 0 {
+		return configPath, nil
+	}
+
+	candidates := []string{defaultConfigFile}
+
+	executable, err := os.Executable()
+	if err == nil {
+		candidates = append(
+			candidates,
+			filepath.Join(filepath.Dir(executable), defaultConfigFile),
+		)
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find config file in %v", candidates)
 }
 
 func ReadConfig(filePath string) (*Config, error) {
 	config := &Config{}
 
-	if _, err := toml.DecodeFile(filePath, config); err != nil {
+	metadata, err := toml.DecodeFile(filePath, config)
+	if err != nil {
 		return nil, fmt.Errorf(
 			"could not decode config file [%v]: [%v]",
 			filePath,
@@ -35,5 +280,61 @@ func ReadConfig(filePath string) (*Config, error) {
 		)
 	}
 
+	// The attestation key password used to be set in the config file. It's
+	// rejected so it's not left there in plain text, silently ignored.
+	if metadata.IsDefined("Billings", "AttestationKeyPassword") {
+		return nil, fmt.Errorf(
+			"AttestationKeyPassword is no longer read from config file [%v]; "+
+				"remove it and set the ATTESTATION_KEY_PASSWORD "+
+				"environment variable instead",
+			filePath,
+		)
+	}
+
+	if config.Ethereum.StartBlock == 0 {
+		config.Ethereum.StartBlock = config.Indexer.StartBlock
+	}
+
+	config.resolvePaths(filepath.Dir(filePath))
+
 	return config, nil
 }
+
+// resolvePaths makes relative paths of the config relative to the given
+// directory of the config file, so the config file found next to the
+// executable works regardless of the working directory.
+func (c *Config) resolvePaths(directory string) {
+	paths := []*string{
+		&c.Billings.CustomersFile,
+		&c.Billings.TargetDirectory,
+		&c.Billings.BeaconTemplateFile,
+		&c.Billings.AnnualStatementTemplateFile,
+		&c.Billings.PartialsDirectory,
+		&c.Billings.TranslationsDirectory,
+		&c.Billings.SigningKeyFile,
+		&c.Billings.AttestationKeyFile,
+		&c.Indexer.StoreFile,
+		&c.Branding.LogoFile,
+		&c.Email.BodyTemplateFile,
+		&c.Prices.File,
+	}
+
+	for _, path := range paths {
+		*path = resolvePath(directory, *path)
+	}
+
+	for name, branding := range c.Brandings {
+		branding.LogoFile = resolvePath(directory, branding.LogoFile)
+		c.Brandings[name] = branding
+	}
+}
+
+// resolvePath returns the path joined with the directory if the path is
+// relative. Empty and absolute paths are returned unchanged.
+func resolvePath(directory, path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(directory, path)
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, directory, content string) string {
+	configPath := filepath.Join(directory, "config.toml")
+	if err := ioutil.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return configPath
+}
+
+func TestReadConfigRejectsAttestationKeyPassword(t *testing.T) {
+	directory, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	configPath := writeConfig(
+		t,
+		directory,
+		"[Billings]\n"+
+			"AttestationKeyFile = \"./keystore.json\"\n"+
+			"AttestationKeyPassword = \"PASSWORD\"\n",
+	)
+
+	if _, err := ReadConfig(configPath); err == nil {
+		t.Fatal("expected error for the attestation key password")
+	}
+}
+
+func TestReadConfigResolvesRelativePaths(t *testing.T) {
+	directory, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	configPath := writeConfig(
+		t,
+		directory,
+		"[Billings]\n"+
+			"CustomersFile = \"./configs/customers.json\"\n"+
+			"TargetDirectory = \"/var/billings\"\n"+
+			"[Indexer]\n"+
+			"StoreFile = \"index/logs.json\"\n"+
+			"[Brandings.partner]\n"+
+			"LogoFile = \"./logos/partner.png\"\n",
+	)
+
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		actual   string
+		expected string
+	}{
+		"relative path": {
+			actual:   config.Billings.CustomersFile,
+			expected: filepath.Join(directory, "configs", "customers.json"),
+		},
+		"absolute path": {
+			actual:   config.Billings.TargetDirectory,
+			expected: "/var/billings",
+		},
+		"path not set": {
+			actual:   config.Billings.SigningKeyFile,
+			expected: "",
+		},
+		"indexer path": {
+			actual:   config.Indexer.StoreFile,
+			expected: filepath.Join(directory, "index", "logs.json"),
+		},
+		"named branding path": {
+			actual:   config.Brandings["partner"].LogoFile,
+			expected: filepath.Join(directory, "logos", "partner.png"),
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf(
+					"unexpected path\nexpected: [%v]\nactual:   [%v]",
+					test.expected,
+					test.actual,
+				)
+			}
+		})
+	}
+}
+
+func TestReadConfigStartBlock(t *testing.T) {
+	directory, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	var tests = map[string]struct {
+		content  string
+		network  string
+		expected uint64
+	}{
+		"default network": {
+			content: "[Ethereum]\n" +
+				"StartBlock = 100\n" +
+				"[Indexer]\n" +
+				"StartBlock = 50\n",
+			expected: 100,
+		},
+		"default network with the indexer start block": {
+			content: "[Indexer]\n" +
+				"StartBlock = 50\n",
+			expected: 50,
+		},
+		"named network": {
+			content: "[Networks.ropsten]\n" +
+				"ChainID = 3\n" +
+				"StartBlock = 200\n" +
+				"[Indexer]\n" +
+				"StartBlock = 50\n",
+			network:  "ropsten",
+			expected: 200,
+		},
+		"named network without start block": {
+			content: "[Networks.ropsten]\n" +
+				"ChainID = 3\n" +
+				"[Indexer]\n" +
+				"StartBlock = 50\n",
+			network:  "ropsten",
+			expected: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			config, err := ReadConfig(writeConfig(t, directory, test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			network, err := config.Network(test.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if network.StartBlock != test.expected {
+				t.Errorf(
+					"unexpected start block\nexpected: [%v]\nactual:   [%v]",
+					test.expected,
+					network.StartBlock,
+				)
+			}
+		})
+	}
+}
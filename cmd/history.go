@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+)
+
+// maxHistoryPeriods is the number of the most recent periods presented on
+// billing charts, including the current one.
+const maxHistoryPeriods = 12
+
+// loadHistory reads billing JSON files generated for the customer in
+// previous runs in the target directory and returns the history of periods
+// ending with the given report. Files not matching their run manifests are
+// skipped, so the history is built only from billings the customer
+// received.
+func loadHistory(
+	targetDirectory string,
+	customer *billing.Customer,
+	report *billing.BeaconReport,
+	fileName string,
+) billing.History {
+	history := make(billing.History, 0)
+
+	current, err := billing.NewHistoryPoint(report)
+	if err != nil {
+		logger.Warnf(
+			"could not present billing history of customer [%v]: [%v]",
+			customer.Name,
+			err,
+		)
+		return history
+	}
+
+	entries, err := ioutil.ReadDir(targetDirectory)
+	if err != nil {
+		logger.Warnf("could not read billing history: [%v]", err)
+		return append(history, current)
+	}
+
+	periods := make(map[uint64]bool)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		runDirectory := filepath.Join(targetDirectory, entry.Name())
+
+		runManifest, err := readManifest(runDirectory)
+		if err != nil ||
+			runManifest.EndBlock >= report.PeriodEndBlock ||
+			periods[runManifest.EndBlock] {
+			continue
+		}
+
+		for _, file := range runManifest.customerFiles(customer.Name) {
+			if file.Name != fileName {
+				continue
+			}
+
+			point, err := readHistoryPoint(runDirectory, file)
+			if err != nil {
+				logger.Warnf(
+					"skipping billing [%v] of customer [%v] in history: [%v]",
+					filepath.Join(runDirectory, file.Name),
+					customer.Name,
+					err,
+				)
+				continue
+			}
+
+			history = append(history, point)
+			periods[runManifest.EndBlock] = true
+		}
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].PeriodEndBlock < history[j].PeriodEndBlock
+	})
+
+	history = append(history, current)
+	if len(history) > maxHistoryPeriods {
+		history = history[len(history)-maxHistoryPeriods:]
+	}
+
+	return history
+}
+
+func readHistoryPoint(
+	runDirectory string,
+	file *manifestFile,
+) (*billing.HistoryPoint, error) {
+	report, err := readBeaconReport(runDirectory, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return billing.NewHistoryPoint(report)
+}
+
+// readBeaconReport reads the billing JSON file listed in the run manifest.
+// Files not matching the manifest are rejected.
+func readBeaconReport(
+	runDirectory string,
+	file *manifestFile,
+) (*billing.BeaconReport, error) {
+	content, err := ioutil.ReadFile(filepath.Join(runDirectory, file.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	if hashOf(content) != file.SHA256 {
+		return nil, fmt.Errorf("file does not match the run manifest")
+	}
+
+	report := &billing.BeaconReport{}
+	if err := json.Unmarshal(content, report); err != nil {
+		return nil, fmt.Errorf("could not decode billing: [%v]", err)
+	}
+
+	if report.Report == nil {
+		return nil, fmt.Errorf("billing has no report")
+	}
+
+	return report, nil
+}
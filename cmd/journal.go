@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/exporter"
+)
+
+const (
+	journalFormatCsv        = "csv"
+	journalFormatXero       = "xero"
+	journalFormatQuickBooks = "quickbooks"
+)
+
+// defaultXeroTaxRate is the Xero tax rate of journal lines if no other
+// tax rate is configured.
+const defaultXeroTaxRate = "Tax Exempt"
+
+// defaultJournalAccounts are used for accounts missing in the config.
+var defaultJournalAccounts = billing.JournalAccounts{
+	RewardsClearing: "Staking Rewards Clearing",
+	ProviderRevenue: "Staking Revenue",
+	CustomerPayable: "Customer Rewards Payable",
+	GasExpense:      "Gas Expense",
+	OperatorWallet:  "Operator Wallet",
+}
+
+// journalAccounts returns the configured chart of accounts complemented with
+// the default accounts.
+func journalAccounts(config JournalAccounts) *billing.JournalAccounts {
+	orDefault := func(account string, defaultAccount string) string {
+		if len(account) == 0 {
+			return defaultAccount
+		}
+		return account
+	}
+
+	return &billing.JournalAccounts{
+		RewardsClearing: orDefault(
+			config.RewardsClearing,
+			defaultJournalAccounts.RewardsClearing,
+		),
+		ProviderRevenue: orDefault(
+			config.ProviderRevenue,
+			defaultJournalAccounts.ProviderRevenue,
+		),
+		CustomerPayable: orDefault(
+			config.CustomerPayable,
+			defaultJournalAccounts.CustomerPayable,
+		),
+		GasExpense: orDefault(
+			config.GasExpense,
+			defaultJournalAccounts.GasExpense,
+		),
+		OperatorWallet: orDefault(
+			config.OperatorWallet,
+			defaultJournalAccounts.OperatorWallet,
+		),
+	}
+}
+
+// journalOutputs returns outputs of the configured journal formats.
+func journalOutputs(config Journal, currency string) ([]*output, error) {
+	xeroTaxRate := config.XeroTaxRate
+	if len(xeroTaxRate) == 0 {
+		xeroTaxRate = defaultXeroTaxRate
+	}
+
+	outputs := make([]*output, 0)
+
+	for _, format := range config.Formats {
+		switch format {
+		case journalFormatCsv:
+			outputs = append(outputs, &output{
+				exporter: exporter.NewCsvExporter(
+					journalRecords(
+						func(report *billing.BeaconReport) [][]string {
+							return billing.JournalRecords(report.Journal, currency)
+						},
+					),
+				),
+				fileNameFormat: "%v_Beacon_Journal.csv",
+				description:    "journal csv",
+				internal:       true,
+			})
+		case journalFormatXero:
+			outputs = append(outputs, &output{
+				exporter: exporter.NewCsvExporter(
+					journalRecords(
+						func(report *billing.BeaconReport) [][]string {
+							return billing.XeroJournalRecords(
+								report.Journal,
+								xeroTaxRate,
+							)
+						},
+					),
+				),
+				fileNameFormat: "%v_Beacon_Journal_Xero.csv",
+				description:    "Xero journal csv",
+				internal:       true,
+			})
+		case journalFormatQuickBooks:
+			outputs = append(outputs, &output{
+				exporter: exporter.NewTsvExporter(
+					journalRecords(
+						func(report *billing.BeaconReport) [][]string {
+							return billing.IifJournalRecords(
+								report.Journal,
+								report.Customer.Name,
+							)
+						},
+					),
+				),
+				fileNameFormat: "%v_Beacon_Journal.iif",
+				description:    "QuickBooks journal iif",
+				internal:       true,
+			})
+		default:
+			return nil, fmt.Errorf("unknown journal format: [%v]", format)
+		}
+	}
+
+	return outputs, nil
+}
+
+func journalRecords(
+	records func(report *billing.BeaconReport) [][]string,
+) func(data interface{}) ([][]string, error) {
+	return func(data interface{}) ([][]string, error) {
+		report, ok := data.(*billing.BeaconReport)
+		if !ok {
+			return nil, fmt.Errorf("unexpected report type: [%T]", data)
+		}
+
+		return records(report), nil
+	}
+}
@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/exporter"
+	"github.com/boar-network/keep-billings/pkg/signing"
+)
+
+const manifestFileName = "manifest.json"
+
+// output is a single file generated for each customer.
+type output struct {
+	exporter exporter.Exporter
+	// exporters used instead of the default one for particular customers,
+	// by customer name
+	customerExporters map[string]exporter.Exporter
+	fileNameFormat    string
+	description       string
+	// internal outputs, like accounting journals, are kept by the provider
+	// and never sent to the customer
+	internal bool
+}
+
+func (o *output) exporterFor(customer *billing.Customer) exporter.Exporter {
+	if customerExporter, ok := o.customerExporters[customer.Name]; ok {
+		return customerExporter
+	}
+
+	return o.exporter
+}
+
+func (o *output) fileName(customer *billing.Customer) string {
+	return fmt.Sprintf(
+		o.fileNameFormat,
+		strings.ReplaceAll(customer.Name, " ", "_"),
+	)
+}
+
+// manifest lists all files generated in a run directory along with their
+// SHA-256 hashes, so the recipients can verify the files were not altered.
+type manifest struct {
+	// chain the outputs were generated for
+	ChainID    uint64
+	StartBlock uint64
+	EndBlock   uint64
+	UpdatedAt  time.Time
+	Files      []*manifestFile
+}
+
+type manifestFile struct {
+	Customer string
+	Name     string
+	SHA256   string
+	Internal bool `json:",omitempty"`
+}
+
+// loadManifest loads the manifest of the run directory or creates a new one
+// if the directory has no manifest yet. Outputs listed in a manifest of a
+// different period, like annual statements generated before the year
+// ended, are stale so a new manifest of the period is created instead.
+// Outputs generated for another chain are never mixed with the ones of the
+// given chain.
+func loadManifest(
+	directory string,
+	chainID uint64,
+	period *billing.Period,
+) (*manifest, error) {
+	runManifest, err := readManifest(directory)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil && runManifest.ChainID != 0 && runManifest.ChainID != chainID {
+		return nil, fmt.Errorf(
+			"outputs in [%v] were generated for chain [%v] instead of [%v]",
+			directory,
+			runManifest.ChainID,
+			chainID,
+		)
+	}
+
+	if err == nil {
+		// manifests written before chain IDs were recorded
+		runManifest.ChainID = chainID
+	}
+
+	if err == nil &&
+		runManifest.StartBlock == period.StartBlock &&
+		runManifest.EndBlock == period.EndBlock {
+		return runManifest, nil
+	}
+
+	if err == nil {
+		logger.Warnf(
+			"outputs in [%v] cover blocks [%v - %v] instead of [%v - %v]; "+
+				"all of them will be generated again",
+			directory,
+			runManifest.StartBlock,
+			runManifest.EndBlock,
+			period.StartBlock,
+			period.EndBlock,
+		)
+	}
+
+	return &manifest{
+		ChainID:    chainID,
+		StartBlock: period.StartBlock,
+		EndBlock:   period.EndBlock,
+		Files:      make([]*manifestFile, 0),
+	}, nil
+}
+
+// readManifest reads the manifest of the run directory. The error satisfies
+// os.IsNotExist if there is no manifest.
+func readManifest(directory string) (*manifest, error) {
+	manifestPath := filepath.Join(directory, manifestFileName)
+
+	manifestBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	runManifest := &manifest{}
+	if err := json.Unmarshal(manifestBytes, runManifest); err != nil {
+		return nil, fmt.Errorf(
+			"could not decode manifest [%v]: [%v]",
+			manifestPath,
+			err,
+		)
+	}
+
+	return runManifest, nil
+}
+
+// update replaces all files of the customer with the given ones.
+func (m *manifest) update(customer string, files []*manifestFile) {
+	updatedFiles := make([]*manifestFile, 0, len(m.Files)+len(files))
+	for _, file := range m.Files {
+		if file.Customer != customer {
+			updatedFiles = append(updatedFiles, file)
+		}
+	}
+
+	updatedFiles = append(updatedFiles, files...)
+
+	sort.Slice(updatedFiles, func(i, j int) bool {
+		if updatedFiles[i].Customer != updatedFiles[j].Customer {
+			return updatedFiles[i].Customer < updatedFiles[j].Customer
+		}
+		return updatedFiles[i].Name < updatedFiles[j].Name
+	})
+
+	m.Files = updatedFiles
+	m.UpdatedAt = time.Now().UTC()
+}
+
+// hasValidOutputs checks whether all outputs of the customer, along with
+// their signatures if signing is enabled, are present in the directory and
+// match the hashes recorded in the manifest.
+func (m *manifest) hasValidOutputs(
+	directory string,
+	customer *billing.Customer,
+	outputs []*output,
+	signer *signing.Signer,
+) bool {
+	names := make([]string, 0)
+	for _, output := range outputs {
+		names = append(names, output.fileName(customer))
+		if signer != nil {
+			names = append(
+				names,
+				output.fileName(customer)+signing.SignatureFileSuffix,
+			)
+		}
+	}
+
+	for _, name := range names {
+		var recorded *manifestFile
+		for _, file := range m.Files {
+			if file.Customer == customer.Name && file.Name == name {
+				recorded = file
+				break
+			}
+		}
+
+		if recorded == nil {
+			return false
+		}
+
+		fileBytes, err := ioutil.ReadFile(filepath.Join(directory, name))
+		if err != nil || hashOf(fileBytes) != recorded.SHA256 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// customerFiles returns all files of the customer listed in the manifest.
+func (m *manifest) customerFiles(customer string) []*manifestFile {
+	files := make([]*manifestFile, 0)
+	for _, file := range m.Files {
+		if file.Customer == customer {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// customerDeliverables returns files of the customer listed in the manifest
+// which can be sent to the customer.
+func (m *manifest) customerDeliverables(customer string) []*manifestFile {
+	files := make([]*manifestFile, 0)
+	for _, file := range m.customerFiles(customer) {
+		if !file.Internal {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+func (m *manifest) save(directory string) error {
+	manifestBytes, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(
+		filepath.Join(directory, manifestFileName),
+		manifestBytes,
+	)
+}
+
+// exportOutputs writes all outputs of the customer to the directory and
+// returns their manifest entries. If the signer is set, a detached
+// signature file is written along with each output. Outputs are written
+// to temporary files first and renamed only when all of them are complete,
+// so a crash or a cancelled run never leaves truncated files. Renames are
+// not atomic as a whole though: if one of them fails, the outputs of the
+// customer are a mix of new and previous ones, so the caller has to drop
+// the customer from the manifest on error.
+func exportOutputs(
+	ctx context.Context,
+	directory string,
+	customer *billing.Customer,
+	report interface{},
+	outputs []*output,
+	signer *signing.Signer,
+) ([]*manifestFile, error) {
+	tempFiles := make(map[string]string)
+	defer func() {
+		for _, tempFile := range tempFiles {
+			os.Remove(tempFile)
+		}
+	}()
+
+	exported := make([]*manifestFile, 0)
+
+	for _, output := range outputs {
+		fileBytes, err := output.exporterFor(customer).Export(report)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not export %v: [%v]",
+				output.description,
+				err,
+			)
+		}
+
+		files := map[string][]byte{output.fileName(customer): fileBytes}
+		if signer != nil {
+			signatureName := output.fileName(customer) + signing.SignatureFileSuffix
+			files[signatureName] = signer.Sign(fileBytes)
+		}
+
+		for name, content := range files {
+			tempFile, err := writeTempFile(directory, name, content)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"could not write %v file: [%v]",
+					output.description,
+					err,
+				)
+			}
+
+			tempFiles[name] = tempFile
+			exported = append(exported, &manifestFile{
+				Customer: customer.Name,
+				Name:     name,
+				SHA256:   hashOf(content),
+				Internal: output.internal,
+			})
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for name, tempFile := range tempFiles {
+		if err := os.Rename(tempFile, filepath.Join(directory, name)); err != nil {
+			return nil, fmt.Errorf("could not write file [%v]: [%v]", name, err)
+		}
+		delete(tempFiles, name)
+	}
+
+	return exported, nil
+}
+
+// writeFileAtomically writes the file so that it's never observed
+// partially written.
+func writeFileAtomically(path string, data []byte) error {
+	tempFile, err := writeTempFile(filepath.Dir(path), filepath.Base(path), data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+
+	return nil
+}
+
+// writeTempFile writes the data to a new temporary file in the directory
+// and returns the temporary file path. The file is flushed to the disk so
+// it can be safely renamed afterwards.
+func writeTempFile(directory string, name string, data []byte) (string, error) {
+	tempFile, err := ioutil.TempFile(directory, "."+name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return tempFile.Name(), nil
+}
+
+func hashOf(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	var tests = map[string]struct {
+		period             *billing.Period
+		expectedFilesCount int
+	}{
+		"same period": {
+			period:             &billing.Period{StartBlock: 100, EndBlock: 200},
+			expectedFilesCount: 1,
+		},
+		"period extended": {
+			period:             &billing.Period{StartBlock: 100, EndBlock: 300},
+			expectedFilesCount: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "outputs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(directory)
+
+			stored := &manifest{
+				StartBlock: 100,
+				EndBlock:   200,
+				Files: []*manifestFile{
+					{Customer: "A", Name: "A_Statement.pdf", SHA256: "aa"},
+				},
+			}
+			if err := stored.save(directory); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := loadManifest(directory, 1, test.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if loaded.StartBlock != test.period.StartBlock ||
+				loaded.EndBlock != test.period.EndBlock {
+				t.Errorf(
+					"unexpected manifest period\nexpected: [%v - %v]\n"+
+						"actual:   [%v - %v]",
+					test.period.StartBlock,
+					test.period.EndBlock,
+					loaded.StartBlock,
+					loaded.EndBlock,
+				)
+			}
+
+			if len(loaded.Files) != test.expectedFilesCount {
+				t.Errorf(
+					"unexpected number of files\nexpected: [%v]\nactual:   [%v]",
+					test.expectedFilesCount,
+					len(loaded.Files),
+				)
+			}
+		})
+	}
+}
+
+func TestLoadManifestOfAnotherChain(t *testing.T) {
+	directory, err := ioutil.TempDir("", "outputs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	stored := &manifest{ChainID: 1, StartBlock: 100, EndBlock: 200}
+	if err := stored.save(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	period := &billing.Period{StartBlock: 100, EndBlock: 200}
+	if _, err := loadManifest(directory, 3, period); err == nil {
+		t.Errorf("expected error loading manifest of another chain")
+	}
+}
+
+// contentExporter exports fixed content or fails if no content is set.
+type contentExporter struct {
+	content []byte
+}
+
+func (ce *contentExporter) Export(data interface{}) ([]byte, error) {
+	if ce.content == nil {
+		return nil, fmt.Errorf("export failed")
+	}
+	return ce.content, nil
+}
+
+func newTestOutputs(first []byte, second []byte) []*output {
+	return []*output{
+		{
+			exporter:       &contentExporter{first},
+			fileNameFormat: "%v_First.txt",
+			description:    "first",
+		},
+		{
+			exporter:       &contentExporter{second},
+			fileNameFormat: "%v_Second.txt",
+			description:    "second",
+		},
+	}
+}
+
+// directoryContent returns the content of all files in the directory,
+// including temporary ones, by file name.
+func directoryContent(t *testing.T, directory string) map[string]string {
+	infos, err := ioutil.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := make(map[string]string)
+	for _, info := range infos {
+		fileBytes, err := ioutil.ReadFile(filepath.Join(directory, info.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content[info.Name()] = string(fileBytes)
+	}
+
+	return content
+}
+
+func TestExportOutputs(t *testing.T) {
+	customer := &billing.Customer{Name: "Customer A"}
+
+	previous := map[string]string{
+		"Customer_A_First.txt":  "old first",
+		"Customer_A_Second.txt": "old second",
+	}
+
+	var tests = map[string]struct {
+		outputs         []*output
+		cancelled       bool
+		expectedError   bool
+		expectedContent map[string]string
+	}{
+		"all outputs exported": {
+			outputs: newTestOutputs([]byte("new first"), []byte("new second")),
+			expectedContent: map[string]string{
+				"Customer_A_First.txt":  "new first",
+				"Customer_A_Second.txt": "new second",
+			},
+		},
+		"export failed": {
+			outputs:         newTestOutputs([]byte("new first"), nil),
+			expectedError:   true,
+			expectedContent: previous,
+		},
+		"run cancelled": {
+			outputs:         newTestOutputs([]byte("new first"), []byte("new second")),
+			cancelled:       true,
+			expectedError:   true,
+			expectedContent: previous,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "outputs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(directory)
+
+			for name, content := range previous {
+				path := filepath.Join(directory, name)
+				if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancelled {
+				cancel()
+			}
+
+			files, err := exportOutputs(
+				ctx,
+				directory,
+				customer,
+				nil,
+				test.outputs,
+				nil,
+			)
+			if test.expectedError != (err != nil) {
+				t.Fatalf(
+					"unexpected error\nexpected: [%v]\nactual:   [%v]",
+					test.expectedError,
+					err,
+				)
+			}
+
+			if !test.expectedError {
+				hashes := make(map[string]string)
+				for _, file := range files {
+					hashes[file.Name] = file.SHA256
+				}
+
+				for name, content := range test.expectedContent {
+					if hashes[name] != hashOf([]byte(content)) {
+						t.Errorf("unexpected hash of [%v]", name)
+					}
+				}
+			}
+
+			// no temporary files are left behind in any case
+			content := directoryContent(t, directory)
+			if !reflect.DeepEqual(test.expectedContent, content) {
+				t.Errorf(
+					"unexpected directory content\nexpected: [%v]\nactual:   [%v]",
+					test.expectedContent,
+					content,
+				)
+			}
+		})
+	}
+}
+
+func TestHasValidOutputs(t *testing.T) {
+	customer := &billing.Customer{Name: "Customer A"}
+
+	var tests = map[string]struct {
+		modify   func(directory string) error
+		expected bool
+	}{
+		"outputs unchanged": {
+			modify:   func(directory string) error { return nil },
+			expected: true,
+		},
+		"output tampered": {
+			modify: func(directory string) error {
+				return ioutil.WriteFile(
+					filepath.Join(directory, "Customer_A_Second.txt"),
+					[]byte("tampered"),
+					0644,
+				)
+			},
+			expected: false,
+		},
+		"output removed": {
+			modify: func(directory string) error {
+				return os.Remove(filepath.Join(directory, "Customer_A_First.txt"))
+			},
+			expected: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "outputs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(directory)
+
+			outputs := newTestOutputs([]byte("first"), []byte("second"))
+
+			files, err := exportOutputs(
+				context.Background(),
+				directory,
+				customer,
+				nil,
+				outputs,
+				nil,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runManifest := &manifest{Files: make([]*manifestFile, 0)}
+			runManifest.update(customer.Name, files)
+
+			if err := test.modify(directory); err != nil {
+				t.Fatal(err)
+			}
+
+			valid := runManifest.hasValidOutputs(directory, customer, outputs, nil)
+			if valid != test.expected {
+				t.Errorf(
+					"unexpected validity\nexpected: [%v]\nactual:   [%v]",
+					test.expected,
+					valid,
+				)
+			}
+		})
+	}
+}
+
+func TestManifestUpdate(t *testing.T) {
+	runManifest := &manifest{
+		Files: []*manifestFile{
+			{Customer: "A", Name: "A_First.txt", SHA256: "a1"},
+			{Customer: "A", Name: "A_Second.txt", SHA256: "a2"},
+			{Customer: "B", Name: "B_First.txt", SHA256: "b1"},
+		},
+	}
+
+	runManifest.update("A", []*manifestFile{
+		{Customer: "A", Name: "A_Third.txt", SHA256: "a3"},
+	})
+
+	actual := make([]string, len(runManifest.Files))
+	for i, file := range runManifest.Files {
+		actual[i] = file.Customer + " " + file.Name + " " + file.SHA256
+	}
+
+	expected := []string{
+		"A A_Third.txt a3",
+		"B B_First.txt b1",
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf(
+			"unexpected manifest files\nexpected: [%v]\nactual:   [%v]",
+			expected,
+			actual,
+		)
+	}
+}
+
+func TestWriteFileAtomically(t *testing.T) {
+	var tests = map[string]struct {
+		// content of the file written before, no file if empty
+		existing string
+	}{
+		"new file": {
+			existing: "",
+		},
+		"existing file": {
+			existing: "previous content",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "outputs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(directory)
+
+			path := filepath.Join(directory, "file.json")
+			if len(test.existing) > 0 {
+				err := ioutil.WriteFile(path, []byte(test.existing), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writeFileAtomically(path, []byte("content")); err != nil {
+				t.Fatal(err)
+			}
+
+			content := directoryContent(t, directory)
+			expected := map[string]string{"file.json": "content"}
+			if !reflect.DeepEqual(expected, content) {
+				t.Errorf(
+					"unexpected directory content\nexpected: [%v]\nactual:   [%v]",
+					expected,
+					content,
+				)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0644 {
+				t.Errorf(
+					"unexpected file mode\nexpected: [%v]\nactual:   [%v]",
+					os.FileMode(0644),
+					info.Mode().Perm(),
+				)
+			}
+		})
+	}
+}
+
+func TestManifestCustomerDeliverables(t *testing.T) {
+	directory, err := ioutil.TempDir("", "outputs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	customer := &billing.Customer{Name: "Customer A"}
+
+	outputs := newTestOutputs([]byte("billing"), []byte("journal"))
+	outputs[1].internal = true
+
+	files, err := exportOutputs(
+		context.Background(),
+		directory,
+		customer,
+		nil,
+		outputs,
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runManifest := &manifest{Files: make([]*manifestFile, 0)}
+	runManifest.update(customer.Name, files)
+
+	deliverables := runManifest.customerDeliverables(customer.Name)
+
+	actual := make([]string, len(deliverables))
+	for i, file := range deliverables {
+		actual[i] = file.Name
+	}
+
+	expected := []string{"Customer_A_First.txt"}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf(
+			"unexpected deliverables\nexpected: [%v]\nactual:   [%v]",
+			expected,
+			actual,
+		)
+	}
+}
@@ -0,0 +1,682 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/chain"
+	"github.com/boar-network/keep-billings/pkg/payout"
+	"github.com/boar-network/keep-billings/pkg/signing"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	payoutFormatTransactions = "transactions"
+	payoutFormatSafe         = "safe"
+)
+
+const (
+	payoutsFileName                = "payouts.json"
+	payoutsSafeBatchFileNameFormat = "payouts_safe_%v.json"
+)
+
+var PayoutsCommand = cli.Command{
+	Name:   "payouts",
+	Action: PreparePayouts,
+	Usage:  "Prepares transactions paying out customers' shares of generated billings",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config,c",
+			Usage: configFlagUsage,
+		},
+		&cli.StringFlag{
+			Name:  "network,n",
+			Usage: "Name of the network profile, [Ethereum] config if not set",
+		},
+		&cli.StringFlag{
+			Name:  "run-directory,d",
+			Usage: "Run directory with the generated billings",
+		},
+		&cli.StringFlag{
+			Name:  "format,f",
+			Value: payoutFormatTransactions,
+			Usage: "Output format: [" + payoutFormatTransactions +
+				"] for unsigned transactions sent by beneficiaries or [" +
+				payoutFormatSafe + "] for Gnosis Safe batches",
+		},
+		&cli.StringFlag{
+			Name: "from",
+			Usage: "Pay out only shares held by the given beneficiary; " +
+				"the key account when broadcasting if not set",
+		},
+		&cli.Uint64Flag{
+			Name:  "gas-price",
+			Usage: "Gas price in gwei, suggested by the node if not set",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Present the payouts summary without writing any files",
+		},
+		&cli.BoolFlag{
+			Name:  "broadcast",
+			Usage: "Sign and broadcast planned transactions after confirmation",
+		},
+		&cli.StringFlag{
+			Name:  "key-file",
+			Usage: "Ethereum keystore file of the beneficiary broadcasting transactions",
+		},
+		&cli.StringFlag{
+			Name:   "key-password",
+			EnvVar: "PAYOUTS_KEY_PASSWORD",
+			Usage:  "Password of the keystore file",
+		},
+	},
+}
+
+// plannedPayouts are unsigned payout transactions planned for a run
+// directory, along with the hashes of broadcast ones.
+type plannedPayouts struct {
+	Transactions []*payout.Transaction
+	UpdatedAt    time.Time
+}
+
+func PreparePayouts(c *cli.Context) error {
+	runDirectory := c.String("run-directory")
+	if len(runDirectory) == 0 {
+		return fmt.Errorf("run directory is not set")
+	}
+
+	format := c.String("format")
+	if format != payoutFormatTransactions && format != payoutFormatSafe {
+		return fmt.Errorf("unknown payouts format: [%v]", format)
+	}
+
+	from := c.String("from")
+	if len(from) > 0 && !common.IsHexAddress(from) {
+		return fmt.Errorf("invalid beneficiary address [%v]", from)
+	}
+
+	broadcast := c.Bool("broadcast")
+	if broadcast {
+		if format != payoutFormatTransactions {
+			return fmt.Errorf("only transactions can be broadcast")
+		}
+		if c.Bool("dry-run") {
+			return fmt.Errorf("dry run can't broadcast transactions")
+		}
+		if len(c.String("key-file")) == 0 {
+			return fmt.Errorf("key file is not set")
+		}
+	}
+
+	configPath, err := resolveConfigPath(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	customers, err := parseCustomers(config)
+	if err != nil {
+		return err
+	}
+
+	network, err := config.Network(c.String("network"))
+	if err != nil {
+		return err
+	}
+
+	runManifest, err := readManifest(runDirectory)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("could not find run manifest: [%v]", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	var signer *signing.EthereumSigner
+	if broadcast {
+		signer, err = signing.NewEthereumSigner(
+			c.String("key-file"),
+			c.String("key-password"),
+		)
+		if err != nil {
+			return fmt.Errorf("could not read key file: [%v]", err)
+		}
+
+		// only transactions of the key account can be broadcast
+		if len(from) == 0 {
+			from = signer.Address().Hex()
+		}
+		if !isSentBy(signer.Address().Hex(), from) {
+			return fmt.Errorf(
+				"key account [%v] is not the selected beneficiary [%v]",
+				signer.Address().Hex(),
+				from,
+			)
+		}
+	}
+
+	ctx, cancel := runContext(config.Billings.RunTimeout.Duration)
+	defer cancel()
+
+	ethereumClient, err := newEthereumClient(ctx, config, network)
+	if err != nil {
+		return err
+	}
+
+	chainID, err := ethereumClient.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get chain ID: [%v]", err)
+	}
+
+	// billings of one chain must never be paid out on another
+	if !chainID.IsUint64() || chainID.Uint64() != runManifest.ChainID {
+		return fmt.Errorf(
+			"billings of the run were generated for chain [%v] "+
+				"but the node serves chain [%v]",
+			runManifest.ChainID,
+			chainID,
+		)
+	}
+
+	previousPayouts, err := loadPlannedPayouts(runDirectory)
+	if err != nil {
+		return err
+	}
+	if previousPayouts != nil {
+		if err := previousPayouts.verifyBroadcast(
+			ctx,
+			ethereumClient,
+			runDirectory,
+		); err != nil {
+			return err
+		}
+
+		if previousPayouts.broadcast(from) > 0 {
+			return fmt.Errorf(
+				"payouts of the run were already broadcast; see [%v]",
+				filepath.Join(runDirectory, payoutsFileName),
+			)
+		}
+	}
+
+	payouts := make([]*payout.Payout, 0)
+
+	for i := range customers.Beacon {
+		customer := &customers.Beacon[i]
+
+		fileName := (&output{fileNameFormat: beaconJsonFileNameFormat}).
+			fileName(customer)
+
+		var billingFile *manifestFile
+		for _, file := range runManifest.customerFiles(customer.Name) {
+			if file.Name == fileName {
+				billingFile = file
+			}
+		}
+
+		if billingFile == nil {
+			logger.Warnf(
+				"no billing of customer [%v] in the run directory",
+				customer.Name,
+			)
+			continue
+		}
+
+		report, err := readBeaconReport(runDirectory, billingFile)
+		if err != nil {
+			return fmt.Errorf(
+				"could not read billing of customer [%v]: [%v]",
+				customer.Name,
+				err,
+			)
+		}
+
+		recipient := customer.PayoutAddress
+		if len(recipient) == 0 {
+			recipient = report.Customer.Owner
+		}
+
+		customerPayouts, err := payout.NewPayouts(
+			report,
+			recipient,
+			network.KeepToken,
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"could not prepare payouts of customer [%v]: [%v]",
+				customer.Name,
+				err,
+			)
+		}
+
+		for _, customerPayout := range customerPayouts {
+			if !isSentBy(customerPayout.From, from) {
+				continue
+			}
+
+			logger.Infof(
+				"payout from [%v]: %v",
+				customerPayout.From,
+				customerPayout.Description(),
+			)
+
+			payouts = append(payouts, customerPayout)
+		}
+	}
+
+	if len(payouts) == 0 {
+		logger.Infof("no customer shares to pay out")
+		return nil
+	}
+
+	if format == payoutFormatSafe {
+		if err := checkPayoutBalances(
+			ctx,
+			ethereumClient,
+			payout.Totals(payouts),
+			nil,
+		); err != nil {
+			return err
+		}
+
+		if c.Bool("dry-run") {
+			return nil
+		}
+
+		return saveSafeBatches(runDirectory, runManifest, payouts, chainID)
+	}
+
+	var gasPrice *big.Int
+	if c.Uint64("gas-price") > 0 {
+		gasPrice = new(big.Int).Mul(
+			new(big.Int).SetUint64(c.Uint64("gas-price")),
+			big.NewInt(1000000000),
+		)
+	}
+
+	transactions, err := payout.PlanTransactions(
+		ctx,
+		ethereumClient,
+		payouts,
+		chainID,
+		gasPrice,
+	)
+	if err != nil {
+		return err
+	}
+
+	fees := make(map[string]*big.Int)
+	for _, transaction := range transactions {
+		logger.Infof(
+			"transaction from [%v] with nonce [%v]: gas [%v], max fee [%v] ETH",
+			transaction.From,
+			uint64(transaction.Nonce),
+			uint64(transaction.Gas),
+			chain.WeiToEth(transaction.Fee()).Text('f', 6),
+		)
+
+		if _, ok := fees[transaction.From]; !ok {
+			fees[transaction.From] = big.NewInt(0)
+		}
+		fees[transaction.From] = new(big.Int).Add(
+			fees[transaction.From],
+			transaction.Fee(),
+		)
+	}
+
+	if err := checkPayoutBalances(
+		ctx,
+		ethereumClient,
+		payout.Totals(payouts),
+		fees,
+	); err != nil {
+		return err
+	}
+
+	if c.Bool("dry-run") {
+		return nil
+	}
+
+	// transactions of other beneficiaries planned before are kept
+	planned := &plannedPayouts{Transactions: make([]*payout.Transaction, 0)}
+	if previousPayouts != nil {
+		for _, transaction := range previousPayouts.Transactions {
+			if !isSentBy(transaction.From, from) {
+				planned.Transactions = append(planned.Transactions, transaction)
+			}
+		}
+	}
+	planned.Transactions = append(planned.Transactions, transactions...)
+
+	if err := planned.save(runDirectory); err != nil {
+		return fmt.Errorf("could not save payouts: [%v]", err)
+	}
+
+	logger.Infof(
+		"saved [%v] unsigned transactions to [%v]",
+		len(transactions),
+		filepath.Join(runDirectory, payoutsFileName),
+	)
+
+	if !broadcast {
+		return nil
+	}
+
+	return broadcastPayouts(
+		ctx,
+		ethereumClient,
+		signer,
+		runDirectory,
+		planned,
+		transactions,
+	)
+}
+
+// isSentBy checks whether the sender is the given beneficiary. All senders
+// match if the beneficiary is not set.
+func isSentBy(sender string, beneficiary string) bool {
+	return len(beneficiary) == 0 || strings.EqualFold(sender, beneficiary)
+}
+
+// checkPayoutBalances presents payout totals of each sender and warns about
+// senders not holding enough tokens. Fees of transactions, by sender, are
+// paid in ETH on top of ETH payouts.
+func checkPayoutBalances(
+	ctx context.Context,
+	ethereumClient *chain.EthereumClient,
+	totals []*payout.Total,
+	fees map[string]*big.Int,
+) error {
+	required := make(map[string]*payout.Total)
+	for _, total := range totals {
+		required[total.From+"/"+total.Symbol] = total
+	}
+
+	// senders paying out only KEEP still pay fees in ETH
+	for sender := range fees {
+		if _, ok := required[sender+"/ETH"]; !ok {
+			ethTotal := &payout.Total{
+				From:      sender,
+				Symbol:    "ETH",
+				BaseUnits: big.NewInt(0),
+			}
+			required[sender+"/ETH"] = ethTotal
+			totals = append(totals, ethTotal)
+		}
+	}
+
+	for _, total := range totals {
+		requiredBaseUnits := total.BaseUnits
+		if len(total.Token) == 0 {
+			if fee, ok := fees[total.From]; ok {
+				requiredBaseUnits = new(big.Int).Add(requiredBaseUnits, fee)
+			}
+		}
+
+		var balance *big.Float
+		var err error
+		if len(total.Token) == 0 {
+			balance, err = ethereumClient.EthBalance(ctx, total.From)
+		} else {
+			balance, err = ethereumClient.KeepBalance(ctx, total.From)
+		}
+		if err != nil {
+			return fmt.Errorf(
+				"could not get %v balance of [%v]: [%v]",
+				total.Symbol,
+				total.From,
+				err,
+			)
+		}
+
+		// both ETH and KEEP have 18 decimals
+		requiredAmount := chain.ToTokenUnits(requiredBaseUnits, 18)
+
+		logger.Infof(
+			"[%v] needs [%v] %v, holds [%v] %v",
+			total.From,
+			requiredAmount.Text('f', 6),
+			total.Symbol,
+			balance.Text('f', 6),
+			total.Symbol,
+		)
+
+		if balance.Cmp(requiredAmount) < 0 {
+			logger.Warnf(
+				"[%v] does not hold enough %v to pay out customers' shares",
+				total.From,
+				total.Symbol,
+			)
+		}
+	}
+
+	return nil
+}
+
+// saveSafeBatches writes a Safe Transaction Builder batch for each
+// beneficiary Safe to the run directory.
+func saveSafeBatches(
+	runDirectory string,
+	runManifest *manifest,
+	payouts []*payout.Payout,
+	chainID *big.Int,
+) error {
+	batches := payout.NewSafeBatches(
+		payouts,
+		chainID,
+		fmt.Sprintf(
+			"Payouts for blocks %v-%v",
+			runManifest.StartBlock,
+			runManifest.EndBlock,
+		),
+		time.Now(),
+	)
+
+	for _, batch := range batches {
+		batchBytes, err := json.MarshalIndent(batch, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		batchPath := filepath.Join(
+			runDirectory,
+			fmt.Sprintf(
+				payoutsSafeBatchFileNameFormat,
+				batch.Meta.CreatedFromSafeAddress,
+			),
+		)
+
+		if err := writeFileAtomically(batchPath, batchBytes); err != nil {
+			return fmt.Errorf("could not save Safe batch: [%v]", err)
+		}
+
+		logger.Infof(
+			"saved Safe batch of [%v] transactions to [%v]",
+			len(batch.Transactions),
+			batchPath,
+		)
+	}
+
+	return nil
+}
+
+// broadcastPayouts signs the given transactions, all planned for the run,
+// with the beneficiary key and broadcasts them once confirmed by the user.
+// The hash of each transaction is saved before it's sent, so payouts are
+// never planned again if the send fails after the node accepted it.
+func broadcastPayouts(
+	ctx context.Context,
+	ethereumClient *chain.EthereumClient,
+	signer *signing.EthereumSigner,
+	runDirectory string,
+	planned *plannedPayouts,
+	transactions []*payout.Transaction,
+) error {
+	for _, transaction := range transactions {
+		if !strings.EqualFold(transaction.From, signer.Address().Hex()) {
+			return fmt.Errorf(
+				"transaction [%v] is sent by [%v] instead of the key account [%v]",
+				transaction.Description,
+				transaction.From,
+				signer.Address().Hex(),
+			)
+		}
+	}
+
+	if !confirm(fmt.Sprintf(
+		"Broadcast [%v] payout transactions from [%v]?",
+		len(transactions),
+		signer.Address().Hex(),
+	)) {
+		logger.Infof("broadcast cancelled")
+		return nil
+	}
+
+	for _, transaction := range transactions {
+		signedTx, err := signer.SignTransaction(
+			transaction.Unsigned(),
+			transaction.ChainID.ToInt(),
+		)
+		if err != nil {
+			return fmt.Errorf("could not sign transaction: [%v]", err)
+		}
+
+		transaction.TxHash = signedTx.Hash().Hex()
+		if err := planned.save(runDirectory); err != nil {
+			return fmt.Errorf("could not save payouts: [%v]", err)
+		}
+
+		if err := ethereumClient.SendTransaction(ctx, signedTx); err != nil {
+			return fmt.Errorf(
+				"could not broadcast transaction [%v]; run the command "+
+					"again to check whether the network received it: [%v]",
+				transaction.Description,
+				err,
+			)
+		}
+
+		logger.Infof(
+			"broadcast transaction [%v]: %v",
+			transaction.TxHash,
+			transaction.Description,
+		)
+	}
+
+	return nil
+}
+
+// confirm asks the user the question and returns true only if the user
+// answers yes.
+func confirm(question string) bool {
+	fmt.Printf("%v Type [yes] to confirm: ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(answer) == "yes"
+}
+
+func loadPlannedPayouts(directory string) (*plannedPayouts, error) {
+	payoutsBytes, err := ioutil.ReadFile(filepath.Join(directory, payoutsFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	planned := &plannedPayouts{}
+	if err := json.Unmarshal(payoutsBytes, planned); err != nil {
+		return nil, fmt.Errorf("could not decode payouts: [%v]", err)
+	}
+
+	return planned, nil
+}
+
+// verifyBroadcast checks whether the network knows each transaction with
+// a recorded hash. Hashes are recorded before transactions are sent, so
+// hashes of transactions the network never received are cleared to let
+// their payouts be planned again.
+func (pp *plannedPayouts) verifyBroadcast(
+	ctx context.Context,
+	ethereumClient *chain.EthereumClient,
+	runDirectory string,
+) error {
+	cleared := false
+
+	for _, transaction := range pp.Transactions {
+		if len(transaction.TxHash) == 0 {
+			continue
+		}
+
+		isKnown, err := ethereumClient.IsTransactionKnown(ctx, transaction.TxHash)
+		if err != nil {
+			return fmt.Errorf(
+				"could not check transaction [%v]: [%v]",
+				transaction.TxHash,
+				err,
+			)
+		}
+
+		if !isKnown {
+			logger.Warnf(
+				"transaction [%v] is unknown to the network, "+
+					"so its payout will be planned again: %v",
+				transaction.TxHash,
+				transaction.Description,
+			)
+
+			transaction.TxHash = ""
+			cleared = true
+		}
+	}
+
+	if !cleared {
+		return nil
+	}
+
+	return pp.save(runDirectory)
+}
+
+// broadcast returns the number of broadcast transactions sent by the given
+// beneficiary, or by all beneficiaries if not set.
+func (pp *plannedPayouts) broadcast(beneficiary string) int {
+	count := 0
+	for _, transaction := range pp.Transactions {
+		if len(transaction.TxHash) > 0 && isSentBy(transaction.From, beneficiary) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (pp *plannedPayouts) save(directory string) error {
+	pp.UpdatedAt = time.Now().UTC()
+
+	payoutsBytes, err := json.MarshalIndent(pp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(
+		filepath.Join(directory, payoutsFileName),
+		payoutsBytes,
+	)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/boar-network/keep-billings/pkg/payout"
+)
+
+func TestPlannedPayoutsBroadcast(t *testing.T) {
+	planned := &plannedPayouts{
+		Transactions: []*payout.Transaction{
+			{From: "0x0000000000000000000000000000000000000011", TxHash: "0x01"},
+			{From: "0x0000000000000000000000000000000000000011"},
+			{From: "0x0000000000000000000000000000000000000012"},
+		},
+	}
+
+	var tests = map[string]struct {
+		beneficiary string
+		expected    int
+	}{
+		"all beneficiaries": {
+			beneficiary: "",
+			expected:    1,
+		},
+		"beneficiary with broadcast transactions": {
+			beneficiary: "0x0000000000000000000000000000000000000011",
+			expected:    1,
+		},
+		"beneficiary without broadcast transactions": {
+			beneficiary: "0x0000000000000000000000000000000000000012",
+			expected:    0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual := planned.broadcast(test.beneficiary)
+			if actual != test.expected {
+				t.Errorf(
+					"unexpected broadcast transactions count\n"+
+						"expected: [%v]\nactual:   [%v]",
+					test.expected,
+					actual,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/assets"
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/mailer"
+	"github.com/urfave/cli"
+)
+
+const (
+	deliveriesFileName = "deliveries.json"
+
+	defaultEmailSubject = "Keep Random Beacon billing for blocks " +
+		"{{.StartBlock}}-{{.EndBlock}}"
+)
+
+const (
+	deliverySent    = "sent"
+	deliveryFailed  = "failed"
+	deliverySkipped = "skipped"
+)
+
+var SendCommand = cli.Command{
+	Name:   "send",
+	Action: SendBillings,
+	Usage:  "Sends generated billings to customers by email",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config,c",
+			Usage: configFlagUsage,
+		},
+		&cli.StringFlag{
+			Name:  "run-directory,d",
+			Usage: "Run directory with the generated billings",
+		},
+		&cli.BoolFlag{
+			Name:  "resend",
+			Usage: "Send billings again to customers who already received them",
+		},
+	},
+}
+
+// deliveries is the summary of billings sent from a run directory.
+type deliveries struct {
+	Deliveries []*delivery
+}
+
+type delivery struct {
+	Customer  string
+	Email     string
+	Status    string
+	Reason    string `json:",omitempty"`
+	Files     []string
+	UpdatedAt time.Time
+}
+
+// emailData is passed to the subject and body templates.
+type emailData struct {
+	Customer   *billing.Customer
+	StartBlock uint64
+	EndBlock   uint64
+	Files      []string
+}
+
+func SendBillings(c *cli.Context) error {
+	configPath, err := resolveConfigPath(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	runDirectory := c.String("run-directory")
+	if len(runDirectory) == 0 {
+		return fmt.Errorf("run directory is not set")
+	}
+
+	if len(config.Email.SmtpHost) == 0 {
+		return fmt.Errorf("SMTP host is not configured")
+	}
+
+	customers, err := parseCustomers(config)
+	if err != nil {
+		return err
+	}
+
+	runManifest, err := readManifest(runDirectory)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("could not find run manifest: [%v]", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	subject := config.Email.Subject
+	if len(subject) == 0 {
+		subject = defaultEmailSubject
+	}
+
+	subjectTemplate, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return fmt.Errorf("could not parse email subject: [%v]", err)
+	}
+
+	body, _ := assets.File(assets.BillingEmailTemplate)
+	if len(config.Email.BodyTemplateFile) > 0 {
+		body, err = ioutil.ReadFile(config.Email.BodyTemplateFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	bodyTemplate, err := template.New("body").Parse(string(body))
+	if err != nil {
+		return fmt.Errorf("could not parse email body template: [%v]", err)
+	}
+
+	smtpMailer := mailer.NewSmtpMailer(
+		config.Email.SmtpHost,
+		config.Email.SmtpPort,
+		config.Email.SmtpUsername,
+		config.Email.SmtpPassword,
+		config.Email.From,
+	)
+
+	runDeliveries, err := loadDeliveries(runDirectory)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(config.Billings.RunTimeout.Duration)
+	defer cancel()
+
+	logger.Infof("sending billings from [%v]", runDirectory)
+
+	failed := 0
+
+	for i := range customers.Beacon {
+		customer := &customers.Beacon[i]
+
+		if ctx.Err() != nil {
+			logger.Warnf(
+				"sending stopped before customer [%v]: [%v]",
+				customer.Name,
+				ctx.Err(),
+			)
+			break
+		}
+
+		previous := runDeliveries.find(customer.Name)
+		if !c.Bool("resend") &&
+			previous != nil &&
+			previous.Status == deliverySent {
+			logger.Infof("billing already sent to [%v]", customer.Name)
+			continue
+		}
+
+		customerDelivery := &delivery{
+			Customer:  customer.Name,
+			Email:     customer.Email,
+			UpdatedAt: time.Now().UTC(),
+		}
+
+		if len(customer.Email) == 0 {
+			logger.Warnf("no email address of customer [%v]", customer.Name)
+
+			customerDelivery.Status = deliverySkipped
+			customerDelivery.Reason = "no email address"
+		} else {
+			message, err := billingMessage(
+				runDirectory,
+				runManifest,
+				customer,
+				subjectTemplate,
+				bodyTemplate,
+			)
+			if err == nil {
+				err = smtpMailer.Send(ctx, message)
+			}
+
+			if err != nil {
+				logger.Errorf(
+					"could not send billing to customer [%v]: [%v]",
+					customer.Name,
+					err,
+				)
+
+				customerDelivery.Status = deliveryFailed
+				customerDelivery.Reason = err.Error()
+				failed++
+			} else {
+				logger.Infof(
+					"sent billing to [%v] at [%v]",
+					customer.Name,
+					customer.Email,
+				)
+
+				customerDelivery.Status = deliverySent
+			}
+
+			for _, file := range runManifest.customerDeliverables(customer.Name) {
+				customerDelivery.Files = append(customerDelivery.Files, file.Name)
+			}
+		}
+
+		runDeliveries.update(customerDelivery)
+		if err := runDeliveries.save(runDirectory); err != nil {
+			return fmt.Errorf("could not save deliveries summary: [%v]", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not send [%v] billings", failed)
+	}
+
+	return ctx.Err()
+}
+
+// billingMessage composes the message with all files generated for the
+// customer except internal ones. Files not matching the manifest are never
+// sent.
+func billingMessage(
+	runDirectory string,
+	runManifest *manifest,
+	customer *billing.Customer,
+	subjectTemplate *template.Template,
+	bodyTemplate *template.Template,
+) (*mailer.Message, error) {
+	files := runManifest.customerDeliverables(customer.Name)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no billing generated in the run directory")
+	}
+
+	data := &emailData{
+		Customer:   customer,
+		StartBlock: runManifest.StartBlock,
+		EndBlock:   runManifest.EndBlock,
+	}
+
+	attachments := make([]*mailer.Attachment, len(files))
+	for i, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(runDirectory, file.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		if hashOf(content) != file.SHA256 {
+			return nil, fmt.Errorf(
+				"file [%v] does not match the run manifest",
+				file.Name,
+			)
+		}
+
+		attachments[i] = &mailer.Attachment{Name: file.Name, Content: content}
+		data.Files = append(data.Files, file.Name)
+	}
+
+	subject := &bytes.Buffer{}
+	if err := subjectTemplate.Execute(subject, data); err != nil {
+		return nil, fmt.Errorf("could not render email subject: [%v]", err)
+	}
+
+	body := &bytes.Buffer{}
+	if err := bodyTemplate.Execute(body, data); err != nil {
+		return nil, fmt.Errorf("could not render email body: [%v]", err)
+	}
+
+	return &mailer.Message{
+		To:          []string{customer.Email},
+		Subject:     subject.String(),
+		Body:        body.String(),
+		Attachments: attachments,
+	}, nil
+}
+
+func loadDeliveries(directory string) (*deliveries, error) {
+	deliveriesBytes, err := ioutil.ReadFile(
+		filepath.Join(directory, deliveriesFileName),
+	)
+	if os.IsNotExist(err) {
+		return &deliveries{Deliveries: make([]*delivery, 0)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	runDeliveries := &deliveries{}
+	if err := json.Unmarshal(deliveriesBytes, runDeliveries); err != nil {
+		return nil, fmt.Errorf("could not decode deliveries summary: [%v]", err)
+	}
+
+	return runDeliveries, nil
+}
+
+func (d *deliveries) find(customer string) *delivery {
+	for _, delivery := range d.Deliveries {
+		if delivery.Customer == customer {
+			return delivery
+		}
+	}
+
+	return nil
+}
+
+// update replaces the previous delivery to the same customer.
+func (d *deliveries) update(customerDelivery *delivery) {
+	for i, delivery := range d.Deliveries {
+		if delivery.Customer == customerDelivery.Customer {
+			d.Deliveries[i] = customerDelivery
+			return
+		}
+	}
+
+	d.Deliveries = append(d.Deliveries, customerDelivery)
+}
+
+func (d *deliveries) save(directory string) error {
+	deliveriesBytes, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(
+		filepath.Join(directory, deliveriesFileName),
+		deliveriesBytes,
+	)
+}
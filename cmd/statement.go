@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boar-network/keep-billings/pkg/assets"
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/exporter"
+	"github.com/boar-network/keep-billings/pkg/price"
+	"github.com/boar-network/keep-billings/pkg/signing"
+	"github.com/urfave/cli"
+)
+
+// defaultCurrency values rewards in annual statements if no other currency
+// is configured.
+const defaultCurrency = "USD"
+
+var AnnualStatementCommand = cli.Command{
+	Name:   "annual-statement",
+	Action: GenerateAnnualStatements,
+	Usage:  "Generates annual statements of rewards valued in fiat for tax filing",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config,c",
+			Usage: configFlagUsage,
+		},
+		&cli.StringFlag{
+			Name:  "network,n",
+			Usage: "Name of the network profile, [Ethereum] config if not set",
+		},
+		&cli.IntFlag{
+			Name:  "year,y",
+			Usage: "Calendar year of the statement, in UTC",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-existing",
+			Usage: "Generate only statements missing in the run directory",
+		},
+	},
+}
+
+func GenerateAnnualStatements(c *cli.Context) error {
+	year := c.Int("year")
+	if year == 0 {
+		return fmt.Errorf("year is not set")
+	}
+
+	configPath, err := resolveConfigPath(c.String("config"))
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("generating annual statements using config [%v]", configPath)
+
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	customers, err := parseCustomers(config)
+	if err != nil {
+		return err
+	}
+
+	network, err := config.Network(c.String("network"))
+	if err != nil {
+		return err
+	}
+
+	currency := config.Prices.Currency
+	if len(currency) == 0 {
+		currency = defaultCurrency
+	}
+
+	prices, err := priceSource(config.Prices, currency)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(config.Billings.RunTimeout.Duration)
+	defer cancel()
+
+	ethereumClient, err := newEthereumClient(ctx, config, network)
+	if err != nil {
+		return err
+	}
+
+	chainID, err := ethereumClient.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get chain ID: [%v]", err)
+	}
+
+	// resolved from the year once the generator is set up
+	period := &billing.Period{}
+
+	statementGenerator := billing.NewAnnualStatementGenerator(
+		ethereumClient,
+		year,
+		period,
+		network.KeepRewardDistributors,
+		rewardTokens(network),
+		prices,
+		currency,
+	)
+
+	statementPdfExporters := newPdfExporters(
+		config,
+		assets.AnnualStatementTemplate,
+		func(customer *billing.Customer) string {
+			return config.Billings.AnnualStatementTemplateFile
+		},
+	)
+
+	statementPdfExporter, err := statementPdfExporters.forCustomer(
+		&billing.Customer{},
+	)
+	if err != nil {
+		return fmt.Errorf("could not create statement pdf exporter: [%v]", err)
+	}
+
+	customerPdfExporters, err := statementPdfExporters.byCustomer(customers.Beacon)
+	if err != nil {
+		return err
+	}
+
+	statementCsvExporter := exporter.NewCsvExporter(
+		func(data interface{}) ([][]string, error) {
+			statement, ok := data.(*billing.AnnualStatement)
+			if !ok {
+				return nil, fmt.Errorf("unexpected statement type: [%T]", data)
+			}
+
+			return statement.Records(), nil
+		},
+	)
+
+	var signer *signing.Signer
+	if len(config.Billings.SigningKeyFile) > 0 {
+		signer, err = signing.NewSigner(config.Billings.SigningKeyFile)
+		if err != nil {
+			return fmt.Errorf("could not read signing key: [%v]", err)
+		}
+	}
+
+	generateBillings(
+		ctx,
+		config.Billings.TargetDirectory,
+		func() string {
+			return fmt.Sprintf("annual_%v", year)
+		},
+		chainID.Uint64(),
+		period,
+		c.Bool("skip-existing"),
+		customers.Beacon,
+		statementGenerator.FetchCommonData,
+		func(
+			ctx context.Context,
+			customer *billing.Customer,
+		) (interface{}, error) {
+			return statementGenerator.Generate(ctx, customer)
+		},
+		[]*output{
+			{
+				exporter:          statementPdfExporter,
+				customerExporters: customerPdfExporters,
+				fileNameFormat:    "%v_Annual_Statement_" + fmt.Sprint(year) + ".pdf",
+				description:       "annual statement pdf",
+			},
+			{
+				exporter:       statementCsvExporter,
+				fileNameFormat: "%v_Annual_Statement_" + fmt.Sprint(year) + ".csv",
+				description:    "annual statement csv",
+			},
+		},
+		signer,
+	)
+
+	logEndpointsStats(ethereumClient.EndpointsStats())
+
+	return ctx.Err()
+}
+
+// priceSource returns the price file if configured or CoinGecko otherwise.
+func priceSource(config Prices, currency string) (billing.PriceSource, error) {
+	if len(config.File) > 0 {
+		prices, err := price.NewFile(config.File)
+		if err != nil {
+			return nil, fmt.Errorf("could not load prices: [%v]", err)
+		}
+
+		logger.Infof(
+			"valuing rewards in [%v] with prices from [%v]",
+			currency,
+			config.File,
+		)
+
+		return prices, nil
+	}
+
+	logger.Infof("valuing rewards in [%v] with prices from CoinGecko", currency)
+
+	return price.NewCoinGecko(config.CoinGeckoURL, currency, config.CoinIDs), nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/boar-network/keep-billings/pkg/assets"
+	"github.com/urfave/cli"
+)
+
+var TemplatesCommand = cli.Command{
+	Name:  "templates",
+	Usage: "Manages templates bundled into the binary",
+	Subcommands: []cli.Command{
+		{
+			Name:   "export",
+			Action: ExportTemplates,
+			Usage:  "Writes bundled templates and translations to be customised",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "directory,d",
+					Value: ".",
+					Usage: "Directory the bundled files are written to",
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "Overwrite existing files",
+				},
+			},
+		},
+	},
+}
+
+func ExportTemplates(c *cli.Context) error {
+	directory := c.String("directory")
+
+	names := append(
+		assets.Names("templates"),
+		assets.Names(assets.TranslationsDirectory)...,
+	)
+
+	for _, name := range names {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+
+		if _, err := os.Stat(path); err == nil && !c.Bool("force") {
+			logger.Warnf("skipping existing file [%v]", path)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			return err
+		}
+
+		content, _ := assets.File(name)
+		if err := writeFileAtomically(path, content); err != nil {
+			return fmt.Errorf("could not write file [%v]: [%v]", path, err)
+		}
+
+		logger.Infof("written [%v]", path)
+	}
+
+	return nil
+}
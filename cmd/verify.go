@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/boar-network/keep-billings/pkg/signing"
+	"github.com/urfave/cli"
+)
+
+var VerifyCommand = cli.Command{
+	Name:      "verify",
+	Action:    VerifySignature,
+	Usage:     "Verifies the detached signature of a generated file",
+	ArgsUsage: "<file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "public-key,k",
+			Usage: "Path to the PEM file with the published public key",
+		},
+		&cli.StringFlag{
+			Name:  "signature,s",
+			Usage: "Path to the signature file, the file path with .sig if not set",
+		},
+	},
+}
+
+func VerifySignature(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one file to verify")
+	}
+
+	file := c.Args().First()
+
+	signatureFile := c.String("signature")
+	if len(signatureFile) == 0 {
+		signatureFile = file + signing.SignatureFileSuffix
+	}
+
+	if len(c.String("public-key")) == 0 {
+		return fmt.Errorf("public key is not set")
+	}
+
+	publicKey, err := signing.ReadPublicKey(c.String("public-key"))
+	if err != nil {
+		return fmt.Errorf("could not read public key: [%v]", err)
+	}
+
+	fileBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	signatureBytes, err := ioutil.ReadFile(signatureFile)
+	if err != nil {
+		return err
+	}
+
+	if err := signing.Verify(publicKey, fileBytes, signatureBytes); err != nil {
+		return fmt.Errorf(
+			"file [%v] does not match signature [%v]: [%v]",
+			file,
+			signatureFile,
+			err,
+		)
+	}
+
+	logger.Infof("file [%v] matches signature [%v]", file, signatureFile)
+
+	return nil
+}
@@ -19,6 +19,12 @@ func main() {
 
 	app.Commands = []cli.Command{
 		cmd.BillingsCommand,
+		cmd.AnnualStatementCommand,
+		cmd.SendCommand,
+		cmd.PayoutsCommand,
+		cmd.VerifyCommand,
+		cmd.VerifyAttestationCommand,
+		cmd.TemplatesCommand,
 	}
 
 	err := app.Run(os.Args)
@@ -0,0 +1,47 @@
+// Package assets bundles default templates and translations into the
+// binary so it does not depend on the working directory. Bundled files are
+// generated from the repository files with `go generate`.
+package assets
+
+//go:generate go run generate.go
+
+import (
+	"sort"
+	"strings"
+)
+
+// Paths of bundled defaults.
+const (
+	BeaconBillingTemplate   = "templates/beacon_billing_template.html"
+	AnnualStatementTemplate = "templates/annual_statement_template.html"
+	BillingEmailTemplate    = "templates/billing_email_template.txt"
+	PartialsDirectory       = "templates/partials"
+	TranslationsDirectory   = "translations"
+)
+
+// File returns the content of the bundled file by its path relative to
+// the repository root, for example `templates/partials/header.html`.
+func File(name string) ([]byte, bool) {
+	content, ok := files[name]
+	if !ok {
+		return nil, false
+	}
+
+	return []byte(content), true
+}
+
+// Names returns sorted paths of all bundled files in the directory and its
+// subdirectories.
+func Names(directory string) []string {
+	prefix := strings.TrimSuffix(directory, "/") + "/"
+
+	names := make([]string, 0)
+	for name := range files {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
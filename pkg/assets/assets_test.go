@@ -0,0 +1,55 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBundleIsUpToDate fails if the bundled files differ from the
+// repository files; run `go generate ./pkg/assets` to fix it.
+func TestBundleIsUpToDate(t *testing.T) {
+	for _, directory := range []string{"templates", TranslationsDirectory} {
+		repositoryFiles := make(map[string]bool)
+
+		err := filepath.Walk(
+			filepath.Join("..", "..", directory),
+			func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				name, err := filepath.Rel(filepath.Join("..", ".."), path)
+				if err != nil {
+					return err
+				}
+				name = filepath.ToSlash(name)
+				repositoryFiles[name] = true
+
+				content, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+
+				bundled, ok := File(name)
+				if !ok {
+					t.Errorf("file [%v] is not bundled", name)
+				} else if string(bundled) != string(content) {
+					t.Errorf("bundled file [%v] is outdated", name)
+				}
+
+				return nil
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range Names(directory) {
+			if !repositoryFiles[name] {
+				t.Errorf("bundled file [%v] no longer exists", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package assets
+
+var files = map[string]string{
+	"templates/annual_statement_template.html": "<html>\n    <head>\n        <meta http-equiv=\"Content-Type\" content=\"text/html; charset=UTF-8\">\n        {{ template \"style\" . }}\n    </head>\n\n    <body>\n        {{ $branding := branding }}\n        {{ $currency := .Currency }}\n        <header class=\"top-header\">\n            {{ with $branding.Logo }}\n                <img class=\"logo\" src=\"{{ . }}\">\n            {{ end }}\n            <h1>{{ label \"statement.title\" .Year }}</h1>\n            {{ template \"issuer\" . }}\n            <p>{{ label \"statement.period\" .PeriodStartDate .PeriodEndDate .PeriodStartBlock .PeriodEndBlock }}</p>\n        </header>\n\n        <h2>{{ label \"staker.title\" }}</h2>\n        <table>\n            <tr>\n                <td class=\"value-name\">{{ label \"staker.name\" }}</td>\n                <td>{{ .Customer.Name }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"staker.operator\" }}</td>\n                <td><a href=\"{{ addressURL .Customer.Operator }}\">{{ .Customer.Operator }}</a></td>\n            </tr>\n            <tr>\n                <td>{{ label \"staker.beneficiary\" }}</td>\n                <td><a href=\"{{ addressURL .Customer.Beneficiary }}\">{{ .Customer.Beneficiary }}</a></td>\n            </tr>\n        </table>\n\n        <h2>{{ label \"statement.totals\" }}</h2>\n        <p>{{ label \"statement.valuation\" $currency }}</p>\n        <table>\n            <tr>\n                <th>{{ label \"statement.token\" }}</th>\n                <th>{{ label \"statement.amount\" }}</th>\n                <th>{{ label \"statement.customer_share\" }}</th>\n                <th>{{ label \"statement.value\" $currency }}</th>\n                <th>{{ label \"statement.customer_share_value\" $currency }}</th>\n            </tr>\n            {{ range .Totals }}\n                <tr>\n                    <td>{{ .Symbol }}</td>\n                    <td>{{ formatAmount .Amount }}</td>\n                    <td>{{ formatAmount .CustomerShare }}</td>\n                    <td>{{ formatAmount .Value }}</td>\n                    <td>{{ formatAmount .CustomerShareValue }}</td>\n                </tr>\n            {{ end }}\n            <tr class=\"final-calculation\">\n                <td colspan=\"3\">{{ label \"statement.total_value\" }}</td>\n                <td>{{ formatAmount .TotalValue }} {{ $currency }}</td>\n                <td>{{ formatAmount .CustomerShareTotalValue }} {{ $currency }}</td>\n            </tr>\n        </table>\n\n        <h2>{{ label \"statement.receipts\" }}</h2>\n        {{ if .Receipts }}\n            <table>\n                <tr>\n                    <th>{{ label \"statement.date\" }}</th>\n                    <th>{{ label \"statement.event\" }}</th>\n                    <th>{{ label \"statement.token\" }}</th>\n                    <th>{{ label \"statement.amount\" }}</th>\n                    <th>{{ label \"statement.customer_share\" }}</th>\n                    <th>{{ label \"statement.price\" $currency }}</th>\n                    <th>{{ label \"statement.value\" $currency }}</th>\n                    <th>{{ label \"statement.customer_share_value\" $currency }}</th>\n                </tr>\n                {{ range .Receipts }}\n                    <tr>\n                        <td>{{ .Date }}</td>\n                        <td>\n                            {{ label (printf \"statement.kind.%v\" .Kind) }}\n                            <br>\n                            <a href=\"{{ txURL .TxHash }}\">{{ shortAddress .TxHash }}</a>\n                        </td>\n                        <td>{{ .Symbol }}</td>\n                        <td>{{ formatAmount .Amount }}</td>\n                        <td>{{ formatAmount .CustomerShare }}</td>\n                        <td>{{ formatAmount .Price }}</td>\n                        <td>{{ formatAmount .Value }}</td>\n                        <td>{{ formatAmount .CustomerShareValue }}</td>\n                    </tr>\n                {{ end }}\n            </table>\n        {{ else }}\n            <p>{{ label \"statement.no_receipts\" }}</p>\n        {{ end }}\n\n        {{ if .UnlockedRewards }}\n            <h2>{{ label \"statement.unlocked\" }}</h2>\n            <p>{{ label \"statement.unlocked_note\" }}</p>\n            <table>\n                <tr>\n                    <th>{{ label \"statement.date\" }}</th>\n                    <th>{{ label \"statement.block\" }}</th>\n                    <th>{{ label \"statement.amount\" }}</th>\n                    <th>{{ label \"statement.customer_share\" }}</th>\n                    <th>{{ label \"statement.price\" $currency }}</th>\n                    <th>{{ label \"statement.value\" $currency }}</th>\n                </tr>\n                {{ range .UnlockedRewards }}\n                    <tr>\n                        <td>{{ .Date }}</td>\n                        <td><a href=\"{{ blockURL .BlockNumber }}\">{{ .BlockNumber }}</a></td>\n                        <td>{{ formatAmount .Amount }} {{ .Symbol }}</td>\n                        <td>{{ formatAmount .CustomerShare }} {{ .Symbol }}</td>\n                        <td>{{ formatAmount .Price }}</td>\n                        <td>{{ formatAmount .Value }}</td>\n                    </tr>\n                {{ end }}\n                <tr class=\"final-calculation\">\n                    <td colspan=\"2\">{{ label \"statement.unlocked_total\" }}</td>\n                    <td colspan=\"3\">{{ formatAmount .UnlockedRewardsTotal }} ETH</td>\n                    <td>{{ formatAmount .UnlockedRewardsTotalValue }} {{ $currency }}</td>\n                </tr>\n            </table>\n        {{ end }}\n    </body>\n</html>\n",
+	"templates/beacon_billing_template.html":   "<html>\n    <head>\n        <meta http-equiv=\"Content-Type\" content=\"text/html; charset=UTF-8\">\n        {{ template \"style\" . }}\n    </head>\n   \n    <body>\n        {{ template \"header\" . }}\n\n        <h2>{{ label \"staker.title\" }}</h2>\n        <table>\n            <tr>\n                <td class=\"value-name\">{{ label \"staker.name\" }}</td>\n                <td>{{ .Customer.Name }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"staker.stake\" }}</td>\n                <td>{{ formatAmount .Stake }} KEEP</td>\n            </tr>\n            <tr>\n                <td>{{ label \"staker.operator\" }}</td>\n                <td><a href=\"{{ addressURL .Customer.Operator }}\">{{ .Customer.Operator }}</a></td>\n            </tr>\n            <tr>\n                <td>{{ label \"staker.owner\" }}</td>\n                <td>{{ .Customer.Owner }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"staker.beneficiary\" }}</td>\n                <td>{{ .Customer.Beneficiary }}</td>\n            </tr>\n        </table>\n\n        <h2>{{ label \"delegation.title\" }}</h2>\n        <table>\n            <tr>\n                <td>{{ label \"delegation.owner\" }}</td>\n                <td>{{ .Delegation.Owner }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.beneficiary\" }}</td>\n                <td>{{ .Delegation.Beneficiary }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.authorizer\" }}</td>\n                <td>{{ .Delegation.Authorizer }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.amount\" }}</td>\n                <td>{{ formatAmount .Delegation.Amount }} KEEP</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.created_at\" }}</td>\n                <td>{{ .Delegation.CreatedAt }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.status\" }}</td>\n                <td>{{ .Delegation.Status }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.operator_contract_authorized\" }}</td>\n                <td>{{ if .Delegation.OperatorContractAuthorized }}{{ label \"yes\" }}{{ else }}{{ label \"no\" }}{{ end }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"delegation.locks\" }}</td>\n                <td>\n                    {{ range .Delegation.Locks }}\n                        <div>{{ . }}</div>\n                    {{ else }}\n                        {{ label \"delegation.not_locked\" }}\n                    {{ end }}\n                </td>\n            </tr>\n        </table>\n\n        <h2>{{ label \"stake_changes.title\" }}</h2>\n        <table>\n            <tr>\n                <td>{{ label \"stake_changes.period_start\" .PeriodStartBlock }}</td>\n                <td>{{ formatAmount .StakeAtPeriodStart }} KEEP</td>\n            </tr>\n            <tr>\n                <td>{{ label \"stake_changes.period_end\" .PeriodEndBlock }}</td>\n                <td>{{ formatAmount .StakeAtPeriodEnd }} KEEP</td>\n            </tr>\n            <tr>\n                <td>{{ label \"stake_changes.delta\" }}</td>\n                <td>{{ formatAmount .StakeDelta }} KEEP</td>\n            </tr>\n            <tr>\n                <td>{{ label \"stake_changes.total_penalties\" }}</td>\n                <td>{{ formatAmount .TotalPenalties }} KEEP</td>\n            </tr>\n        </table>\n\n        {{ if .StakePenalties }}\n        <h3>{{ label \"penalties.title\" }}</h3>\n        <table>\n            <tr>\n                <th class=\"block-number\">{{ label \"penalties.block\" }}</th>\n                <th class=\"transaction-hash\">{{ label \"penalties.transaction\" }}</th>\n                <th class=\"operation\">{{ label \"penalties.penalty\" }}</th>\n                <th class=\"transaction-fee\">{{ label \"penalties.amount\" }}</th>\n            </tr>\n            {{ range .StakePenalties }}\n                <tr>\n                    <td><a href=\"{{ blockURL .BlockNumber }}\">{{ .BlockNumber }}</a></td>\n                    <td><a href=\"{{ txURL .TxHash }}\">{{ .TxHash }}</a></td>\n                    <td>{{ .Type }}</td>\n                    <td>{{ formatAmount .Amount }} KEEP</td>\n                </tr>\n            {{ end }}\n        </table>\n        {{ end }}\n\n        <h2>{{ label \"rewards.title\" }}</h2>\n        <table>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend final-calculation\">{{ label \"rewards.customer_eth_share\" }}</div>\n                    <div class=\"legend\">RS&times;AR+BB</div>\n                </td>\n                <td class=\"final-calculation\">{{ .CustomerEthShare}} ETH</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend final-calculation\">{{ label \"rewards.customer_keep_share\" }}</div>\n                    <div class=\"legend\">RS&times;BK</div>\n                </td>\n                <td class=\"final-calculation\">{{ formatAmount .CustomerKeepShare }} KEEP</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"rewards.provider_eth_share\" }}</div>\n                    <div class=\"legend\">(1-RS)&times;AR</div>\n                </td>\n                <td class>{{ .ProviderEthShare}} ETH</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"rewards.provider_keep_share\" }}</div>\n                    <div class=\"legend\">(1-RS)&times;BK</div>\n                </td>\n                <td class>{{ formatAmount .ProviderKeepShare }} KEEP</td>\n            </tr>\n            {{ range .TokenRewards }}\n            <tr>\n                <td>\n                    <div class=\"label-with-legend final-calculation\">{{ label \"rewards.customer_token_share\" .Symbol }}</div>\n                    <div class=\"legend\">RS&times;B{{ .Symbol }}</div>\n                </td>\n                <td class=\"final-calculation\">{{ .CustomerShare }} {{ .Symbol }}</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"rewards.provider_token_share\" .Symbol }}</div>\n                    <div class=\"legend\">(1-RS)&times;B{{ .Symbol }}</div>\n                </td>\n                <td>{{ .ProviderShare }} {{ .Symbol }}</td>\n            </tr>\n            {{ end }}\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"rewards.customer_share_percentage\" }}</div>\n                    <div class=\"legend\">RS</div>\n                </td>\n                <td>{{ .Customer.CustomerSharePercentage }} %</td>\n            </tr>\n        </table>\n\n\n        <h2>{{ label \"balances.title\" }}</h2>\n        <table>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.beneficiary_keep\" }}</div>\n                    <div class=\"legend\">BK</div>\n                </td>\n                <td>{{ formatAmount .BeneficiaryKeepBalance }} KEEP</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.beneficiary_eth\" }}</div>\n                    <div class=\"legend\">BB</div>\n                </td>\n                <td>{{ .BeneficiaryEthBalance }} ETH</td>\n            </tr>\n            {{ range .TokenRewards }}\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.beneficiary_token\" .Symbol }}</div>\n                    <div class=\"legend\">B{{ .Symbol }}</div>\n                </td>\n                <td>{{ .Received }} {{ .Symbol }}</td>\n            </tr>\n            {{ end }}\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.operator_eth\" }}</div>\n                    <div class=\"legend\">OB</div>\n                </td>\n                <td>{{ .OperatorBalance }} ETH</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.accumulated_rewards\" }}</div>\n                    <div class=\"legend\">AR</div></td>\n                <td>{{ .AccumulatedRewards }} ETH</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.beneficiary_raw_keep\" }}</div>\n                    <div class=\"legend\">{{ label \"balances.informational\" }}</div>\n                </td>\n                <td>{{ formatAmount .BeneficiaryRawKeepBalance }} KEEP</td>\n            </tr>\n            <tr>\n                <td>\n                    <div class=\"label-with-legend\">{{ label \"balances.beneficiary_raw_eth\" }}</div>\n                    <div class=\"legend\">{{ label \"balances.informational\" }}</div>\n                </td>\n                <td>{{ .BeneficiaryRawEthBalance }} ETH</td>\n            </tr>\n        </table>\n\n        <h2>{{ label \"ledger.title\" }}</h2>\n        <table>\n            <tr>\n                <td>{{ label \"ledger.opening_balance\" }}</td>\n                <td>{{ formatAmount .KeepLedgerOpeningBalance }} KEEP</td>\n            </tr>\n            <tr>\n                <td>{{ label \"ledger.closing_balance\" }}</td>\n                <td>{{ formatAmount .KeepLedgerClosingBalance }} KEEP</td>\n            </tr>\n        </table>\n\n        {{ if .KeepLedger }}\n        <h3>{{ label \"ledger.transfers\" }}</h3>\n        <table>\n            <tr>\n                <th class=\"block-number\">{{ label \"ledger.block\" }}</th>\n                <th class=\"counterparty\">{{ label \"ledger.counterparty\" }}</th>\n                <th>{{ label \"ledger.amount\" }}</th>\n                <th>{{ label \"ledger.balance\" }}</th>\n            </tr>\n            {{ range .KeepLedger }}\n                <tr>\n                    <td><a href=\"{{ txURL .TxHash }}\">{{ .BlockNumber }}</a></td>\n                    <td><a href=\"{{ addressURL .Counterparty }}\">{{ shortAddress .Counterparty }}</a></td>\n                    <td>{{ formatAmount .Amount }} KEEP</td>\n                    <td>{{ formatAmount .Balance }} KEEP</td>\n                </tr>\n            {{ end }}\n        </table>\n        {{ end }}\n\n        <h2>{{ label \"groups.title\" }}</h2>\n        <table>\n            <tr>\n                <td>{{ label \"groups.total\" }}</td>\n                <td>{{ .TotalGroupsCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"groups.active\" }}</td>\n                <td>{{ .ActiveGroupsCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"groups.active_members\" }}</td>\n                <td>{{ .ActiveGroupsMembersCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"groups.inactive_members\" }}</td>\n                <td>{{ .InactiveGroupsMembersCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"groups.unlocking_rewards\" .UnlockingRewardsDays }}</td>\n                <td>{{ .UnlockingRewards }} ETH</td>\n            </tr>\n        </table>\n\n        <h3>{{ label \"operator_contracts.title\" }}</h3>\n        <table>\n            <tr>\n                <th>{{ label \"operator_contracts.contract\" }}</th>\n                <th class=\"counterparty\">{{ label \"operator_contracts.address\" }}</th>\n                <th>{{ label \"operator_contracts.groups\" }}</th>\n                <th>{{ label \"operator_contracts.active_groups\" }}</th>\n                <th>{{ label \"operator_contracts.active_members\" }}</th>\n                <th>{{ label \"operator_contracts.inactive_members\" }}</th>\n                <th>{{ label \"operator_contracts.accumulated_rewards\" }}</th>\n            </tr>\n            {{ range .OperatorContractsSummary }}\n                <tr>\n                    <td>{{ .Label }}</td>\n                    <td><a href=\"{{ addressURL .Address }}\">{{ .Address }}</a></td>\n                    <td>{{ .TotalGroupsCount }}</td>\n                    <td>{{ .ActiveGroupsCount }}</td>\n                    <td>{{ .ActiveGroupsMembersCount }}</td>\n                    <td>{{ .InactiveGroupsMembersCount }}</td>\n                    <td>{{ .AccumulatedRewards }} ETH</td>\n                </tr>\n            {{ end }}\n        </table>\n\n        <h2>{{ label \"service_quality.title\" }}</h2>\n        <table>\n            <tr>\n                <td>{{ label \"service_quality.requested\" }}</td>\n                <td>{{ .RelayEntriesRequestedCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"service_quality.produced\" }}</td>\n                <td>{{ .RelayEntriesProducedCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"service_quality.timeouts\" }}</td>\n                <td>{{ .RelayEntryTimeoutsCount }}</td>\n            </tr>\n            <tr>\n                <td>{{ label \"service_quality.dkg_results\" }}</td>\n                <td>{{ .DkgResultsSubmittedCount }}</td>\n            </tr>\n        </table>\n\n        <h2>{{ label \"gas_costs.title\" }}</h2>\n        {{ if .GasCosts }}\n            <table>\n                <tr>\n                    <th class=\"block-number\">{{ label \"gas_costs.block\" }}</th>\n                    <th class=\"transaction-hash\">{{ label \"gas_costs.transaction\" }}</th>\n                    <th class=\"operation\">{{ label \"gas_costs.operation\" }}</th>\n                    <th>{{ label \"gas_costs.gas_used\" }}</th>\n                    <th>{{ label \"gas_costs.gas_price\" }}</th>\n                    <th class=\"transaction-fee\">{{ label \"gas_costs.fee\" }}</th>\n                </tr>\n                {{ range .GasCosts }}\n                    <tr>\n                        <td><a href=\"{{ blockURL .BlockNumber }}\">{{ .BlockNumber }}</a></td>\n                        <td><a href=\"{{ txURL .TxHash }}\">{{ shortAddress .TxHash }}</a></td>\n                        <td>{{ label (printf \"gas_costs.operation.%v\" .Operation) }}</td>\n                        <td>{{ .GasUsed }}</td>\n                        <td>{{ formatAmount .GasPrice }} Gwei</td>\n                        <td>{{ formatAmount .Fee }} ETH</td>\n                    </tr>\n                {{ end }}\n                <tr class=\"final-calculation\">\n                    <td colspan=\"5\">{{ label \"gas_costs.total\" }}</td>\n                    <td>{{ formatAmount .TotalGasCosts }} ETH</td>\n                </tr>\n            </table>\n        {{ else }}\n            <p>{{ label \"gas_costs.none\" }}</p>\n        {{ end }}\n\n        {{ if gt (len .History) 1 }}\n            <h2>{{ label \"history.title\" }}</h2>\n            <div class=\"chart\">\n                {{ barChart (label \"history.eth_rewards\") .History.Labels .History.CustomerEthShares }}\n            </div>\n            <div class=\"chart\">\n                {{ barChart (label \"history.keep_rewards\") .History.Labels .History.CustomerKeepShares }}\n            </div>\n            <div class=\"chart\">\n                {{ lineChart (label \"history.stake\") .History.Labels .History.Stakes }}\n            </div>\n            <div class=\"chart\">\n                {{ lineChart (label \"history.active_members\") .History.Labels .History.ActiveGroupsMembersCounts }}\n            </div>\n        {{ end }}\n\n        <h2>{{ label \"active_groups.title\" }}</h2>\n\n        <table>\n            <tr>\n                <th>{{ label \"active_groups.contract\" }}</th>\n                <th class=\"group-key\">{{ label \"active_groups.group\" }}</th>\n                <th>{{ label \"active_groups.members\" }}</th>\n                <th>{{ label \"active_groups.registration_block\" }}</th>\n                <th>{{ label \"active_groups.stale_block\" }}</th>\n                <th>{{ label \"active_groups.stale_date\" }}</th>\n            </tr>\n            {{ range .ActiveGroupsSummary }}\n                <tr>\n                    <td>{{ .OperatorContract }}</td>\n                    <td>{{ .PublicKey }}</td>\n                    <td>{{ .Members }}</td>\n                    <td>{{ .RegistrationBlock }}</td>\n                    <td>{{ .StaleBlock }}</td>\n                    <td>{{ .StaleDate }}</td>\n                </tr>\n            {{ end }}\n        </table>\n\n        {{ template \"footer\" . }}\n    </body>\n</html>",
+	"templates/billing_email_template.txt":     "Hello {{.Customer.Name}},\n\nplease find attached your Keep Random Beacon billing for blocks\n{{.StartBlock}}-{{.EndBlock}} of operator {{.Customer.Operator}}:\n{{range .Files}}\n- {{.}}{{end}}\n\nKind regards\n",
+	"templates/partials/footer.html":           "{{ define \"footer\" }}\n        {{ if .Attestation }}\n            <div class=\"attestation\">\n                <p>\n                    {{ label \"footer.attestation\" .Attestation.Signer .Attestation.Signature }}\n                </p>\n                <pre>{{ .Attestation.Message }}</pre>\n            </div>\n        {{ end }}\n{{ end }}\n",
+	"templates/partials/header.html":           "{{ define \"header\" }}\n        {{ $branding := branding }}\n        <header class=\"top-header\">\n            {{ with $branding.Logo }}\n                <img class=\"logo\" src=\"{{ . }}\">\n            {{ end }}\n            <h1>{{ label \"header.title\" }}</h1>\n            {{ template \"issuer\" . }}\n            <p>{{ label \"header.thank_you\" }}</p>\n            <p>{{ label \"header.reporting_period\" .PeriodStartBlock .PeriodEndBlock }}</p>\n        </header>\n{{ end }}\n\n{{ define \"issuer\" }}\n        {{ $branding := branding }}\n        <p>{{ label \"header.issued_by\" }} <a href=\"{{ $branding.Website }}\">{{ $branding.Name }}</a></p>\n        {{ with $branding.Address }}\n            <p>{{ . }}</p>\n        {{ end }}\n        {{ with $branding.VatID }}\n            <p>{{ label \"header.vat_id\" }}: {{ . }}</p>\n        {{ end }}\n{{ end }}\n",
+	"templates/partials/style.html":            "{{ define \"style\" }}\n        {{ $branding := branding }}\n        <style>\n            table {\n                width: 100%;\n                border-collapse: collapse;\n                table-layout: fixed;\n            }\n    \n            table, th, tr, td {\n                border: 1px solid gray;\n            }\n    \n            th, td {\n                padding: 15px;\n                text-align: left;\n                word-wrap: break-word\n            }\n    \n            .top-header {\n                text-align: center;\n                padding-bottom: 50px;\n            }\n\n            .logo {\n                max-height: 80px;\n            }\n\n            {{ with $branding.PrimaryColor }}\n            h1, h2, h3 {\n                color: {{ . }};\n            }\n            {{ end }}\n\n            {{ with $branding.AccentColor }}\n            th {\n                background-color: {{ . }};\n            }\n            {{ end }}\n    \n            .attestation {\n                padding-top: 50px;\n                font-size: small;\n                color: gray;\n                word-wrap: break-word;\n            }\n\n            .chart {\n                padding-bottom: 20px;\n                page-break-inside: avoid;\n            }\n\n            .block-number {\n                width: 15%;\n            }\n            .transaction-hash {\n                width: 35%;\n            }\n            .transaction-fee {\n                width: 30%;\n            }\n            .operation {\n                width: 20%;\n            }\n            .group-key {\n                width: 30%;\n            }\n            .counterparty {\n                width: 40%;\n            }\n    \n            .label-with-legend {\n                float: left;\n            }\n            .legend { \n                float: right;\n                text-align: right;\n                font-style: italic;\n                font-family: monospace;\n            }\n    \n            .final-calculation {\n                font-weight: bold;\n            }\n        </style>\n{{ end }}\n",
+	"translations/de.json":                     "{\n  \"number.thousands_separator\": \".\",\n  \"number.decimal_separator\": \",\",\n  \"header.title\": \"Keep Random Beacon Staking-Bericht\",\n  \"header.issued_by\": \"Ausgestellt von\",\n  \"header.vat_id\": \"USt-IdNr.\",\n  \"header.thank_you\": \"Vielen Dank für Ihr Vertrauen in uns mit Ihren KEEP ♥\",\n  \"header.reporting_period\": \"Berichtszeitraum: Blöcke %v – %v\",\n  \"staker.title\": \"Staker\",\n  \"staker.name\": \"Name\",\n  \"staker.stake\": \"Stake\",\n  \"staker.operator\": \"Operator\",\n  \"staker.owner\": \"Eigentümer\",\n  \"staker.beneficiary\": \"Begünstigter\",\n  \"delegation.title\": \"Delegation\",\n  \"delegation.owner\": \"Eigentümer\",\n  \"delegation.beneficiary\": \"Begünstigter\",\n  \"delegation.authorizer\": \"Autorisierer\",\n  \"delegation.amount\": \"Delegierter Betrag\",\n  \"delegation.created_at\": \"Erstellt am\",\n  \"delegation.status\": \"Status\",\n  \"delegation.operator_contract_authorized\": \"Operator-Vertrag autorisiert\",\n  \"delegation.locks\": \"Sperren\",\n  \"yes\": \"Ja\",\n  \"no\": \"Nein\",\n  \"delegation.not_locked\": \"Nicht gesperrt\",\n  \"stake_changes.title\": \"Stake-Änderungen\",\n  \"stake_changes.period_start\": \"Stake zu Beginn des Zeitraums (Block %v)\",\n  \"stake_changes.period_end\": \"Stake am Ende des Zeitraums (Block %v)\",\n  \"stake_changes.delta\": \"Stake-Änderung\",\n  \"stake_changes.total_penalties\": \"Insgesamt gekürzter und beschlagnahmter Stake\",\n  \"penalties.title\": \"Strafen\",\n  \"penalties.block\": \"Block\",\n  \"penalties.transaction\": \"Transaktion\",\n  \"penalties.penalty\": \"Strafe\",\n  \"penalties.amount\": \"Betrag\",\n  \"rewards.title\": \"Belohnungen\",\n  \"rewards.customer_eth_share\": \"ETH-Anteil des Stakers\",\n  \"rewards.customer_keep_share\": \"KEEP-Anteil des Stakers\",\n  \"rewards.provider_eth_share\": \"ETH-Anteil des Anbieters\",\n  \"rewards.provider_keep_share\": \"KEEP-Anteil des Anbieters\",\n  \"rewards.customer_token_share\": \"%v-Anteil des Stakers\",\n  \"rewards.provider_token_share\": \"%v-Anteil des Anbieters\",\n  \"rewards.customer_share_percentage\": \"Prozentualer Belohnungsanteil des Stakers\",\n  \"balances.title\": \"Guthaben\",\n  \"balances.beneficiary_keep\": \"Vom Begünstigten erhaltene KEEP-Belohnungen\",\n  \"balances.beneficiary_eth\": \"An den Begünstigten ausgezahlte ETH-Belohnungen\",\n  \"balances.beneficiary_token\": \"Vom Begünstigten erhaltene %v-Belohnungen\",\n  \"balances.operator_eth\": \"ETH-Guthaben des Operators\",\n  \"balances.accumulated_rewards\": \"Angesammelte ETH-Belohnungen\",\n  \"balances.beneficiary_raw_keep\": \"KEEP-Guthaben des Begünstigten\",\n  \"balances.beneficiary_raw_eth\": \"ETH-Guthaben des Begünstigten\",\n  \"balances.informational\": \"informativ\",\n  \"ledger.title\": \"KEEP-Kontobuch des Begünstigten\",\n  \"ledger.opening_balance\": \"Guthaben zu Beginn des Zeitraums\",\n  \"ledger.closing_balance\": \"Guthaben am Ende des Zeitraums\",\n  \"ledger.transfers\": \"Überweisungen\",\n  \"ledger.block\": \"Block\",\n  \"ledger.counterparty\": \"Gegenpartei\",\n  \"ledger.amount\": \"Betrag\",\n  \"ledger.balance\": \"Guthaben\",\n  \"groups.title\": \"Gruppen\",\n  \"groups.total\": \"Gesamtzahl der im Netzwerk erstellten Gruppen\",\n  \"groups.active\": \"Anzahl der aktiven Beacon-Gruppen im Netzwerk\",\n  \"groups.active_members\": \"Gesamtzahl Ihrer Mitglieder in aktiven Gruppen\",\n  \"groups.inactive_members\": \"Gesamtzahl Ihrer Mitglieder in nicht mehr aktiven Gruppen\",\n  \"groups.unlocking_rewards\": \"Voraussichtlich in den nächsten %v Tagen freiwerdende ETH-Belohnungen\",\n  \"operator_contracts.title\": \"Operator-Verträge\",\n  \"operator_contracts.contract\": \"Vertrag\",\n  \"operator_contracts.address\": \"Adresse\",\n  \"operator_contracts.groups\": \"Gruppen\",\n  \"operator_contracts.active_groups\": \"Aktive Gruppen\",\n  \"operator_contracts.active_members\": \"Ihre Mitglieder in aktiven Gruppen\",\n  \"operator_contracts.inactive_members\": \"Ihre Mitglieder in inaktiven Gruppen\",\n  \"operator_contracts.accumulated_rewards\": \"Angesammelte Belohnungen\",\n  \"service_quality.title\": \"Servicequalität\",\n  \"service_quality.requested\": \"Von Ihren Gruppen angeforderte Relay-Einträge\",\n  \"service_quality.produced\": \"Von Ihren Gruppen erzeugte Relay-Einträge\",\n  \"service_quality.timeouts\": \"Zeitüberschreitungen von Relay-Einträgen Ihrer Gruppen\",\n  \"service_quality.dkg_results\": \"Von Ihrem Operator eingereichte DKG-Ergebnisse\",\n  \"gas_costs.title\": \"Gaskosten des Operators\",\n  \"gas_costs.block\": \"Block\",\n  \"gas_costs.transaction\": \"Transaktion\",\n  \"gas_costs.operation\": \"Vorgang\",\n  \"gas_costs.gas_used\": \"Verbrauchtes Gas\",\n  \"gas_costs.gas_price\": \"Gaspreis\",\n  \"gas_costs.fee\": \"Gebühr\",\n  \"gas_costs.total\": \"Gaskosten gesamt\",\n  \"gas_costs.none\": \"Ihr Operator hat im Zeitraum keine Relay-Einträge, DKG-Ergebnisse oder Belohnungsauszahlungen gesendet.\",\n  \"gas_costs.operation.relay_entry\": \"Relay-Eintrag\",\n  \"gas_costs.operation.dkg_result\": \"DKG-Ergebnis\",\n  \"gas_costs.operation.rewards_withdrawal\": \"Auszahlung von Belohnungen\",\n  \"history.title\": \"Abrechnungsverlauf\",\n  \"history.eth_rewards\": \"Ihre ETH-Belohnungen pro Zeitraum\",\n  \"history.keep_rewards\": \"Ihre KEEP-Belohnungen pro Zeitraum\",\n  \"history.stake\": \"Stake am Ende des Zeitraums (KEEP)\",\n  \"history.active_members\": \"Mitglieder aktiver Gruppen am Ende des Zeitraums\",\n  \"active_groups.title\": \"Mitglieder aktiver Gruppen\",\n  \"active_groups.contract\": \"Vertrag\",\n  \"active_groups.group\": \"Gruppe\",\n  \"active_groups.members\": \"Mitglieder\",\n  \"active_groups.registration_block\": \"Registriert in Block\",\n  \"active_groups.stale_block\": \"Veraltet ab Block\",\n  \"active_groups.stale_date\": \"Voraussichtliches Ablaufdatum\",\n  \"statement.title\": \"Jahresübersicht der Staking-Belohnungen %v\",\n  \"statement.period\": \"Zeitraum: %v – %v, Blöcke %v – %v\",\n  \"statement.valuation\": \"Belohnungen werden in %v zum Tokenkurs des Tages ihres Eingangs bewertet.\",\n  \"statement.totals\": \"Summen\",\n  \"statement.receipts\": \"Erhaltene Belohnungen\",\n  \"statement.no_receipts\": \"Im Laufe des Jahres wurden keine Belohnungen erhalten.\",\n  \"statement.date\": \"Datum\",\n  \"statement.event\": \"Ereignis\",\n  \"statement.block\": \"Block\",\n  \"statement.token\": \"Token\",\n  \"statement.amount\": \"Betrag\",\n  \"statement.customer_share\": \"Ihr Anteil\",\n  \"statement.price\": \"Kurs (%v)\",\n  \"statement.value\": \"Wert (%v)\",\n  \"statement.customer_share_value\": \"Wert Ihres Anteils (%v)\",\n  \"statement.total_value\": \"Gesamtwert\",\n  \"statement.kind.withdrawal\": \"Auszahlung von ETH-Belohnungen\",\n  \"statement.kind.distribution\": \"Verteilung von Belohnungen\",\n  \"statement.kind.group_expiry\": \"Ablauf einer Gruppe\",\n  \"statement.unlocked\": \"Durch den Ablauf von Gruppen freigegebene Belohnungen\",\n  \"statement.unlocked_note\": \"ETH-Belohnungen einer Gruppe werden mit ihrem Ablauf auszahlbar und mit der Auszahlung erhalten. Sie werden nur zur Information aufgeführt und sind in den Summen nicht enthalten.\",\n  \"statement.unlocked_total\": \"Insgesamt freigegeben\",\n  \"footer.attestation\": \"Die Zahlen dieser Abrechnung werden vom Ethereum-Konto %v mit der EIP-191-Signatur %v der folgenden Nachricht bestätigt:\"\n}\n",
+	"translations/en.json":                     "{\n  \"number.thousands_separator\": \",\",\n  \"number.decimal_separator\": \".\",\n  \"header.title\": \"Keep Random Beacon Staking Report\",\n  \"header.issued_by\": \"Issued by\",\n  \"header.vat_id\": \"VAT ID\",\n  \"header.thank_you\": \"Thank you for trusting us with your KEEP ♥\",\n  \"header.reporting_period\": \"Reporting period: blocks %v – %v\",\n  \"staker.title\": \"Staker\",\n  \"staker.name\": \"Name\",\n  \"staker.stake\": \"Stake\",\n  \"staker.operator\": \"Operator\",\n  \"staker.owner\": \"Owner\",\n  \"staker.beneficiary\": \"Beneficiary\",\n  \"delegation.title\": \"Delegation\",\n  \"delegation.owner\": \"Owner\",\n  \"delegation.beneficiary\": \"Beneficiary\",\n  \"delegation.authorizer\": \"Authorizer\",\n  \"delegation.amount\": \"Delegated amount\",\n  \"delegation.created_at\": \"Created at\",\n  \"delegation.status\": \"Status\",\n  \"delegation.operator_contract_authorized\": \"Operator contract authorized\",\n  \"delegation.locks\": \"Locks\",\n  \"yes\": \"Yes\",\n  \"no\": \"No\",\n  \"delegation.not_locked\": \"Not locked\",\n  \"stake_changes.title\": \"Stake Changes\",\n  \"stake_changes.period_start\": \"Stake at the beginning of the period (block %v)\",\n  \"stake_changes.period_end\": \"Stake at the end of the period (block %v)\",\n  \"stake_changes.delta\": \"Stake change\",\n  \"stake_changes.total_penalties\": \"Total slashed and seized stake\",\n  \"penalties.title\": \"Penalties\",\n  \"penalties.block\": \"Block\",\n  \"penalties.transaction\": \"Transaction\",\n  \"penalties.penalty\": \"Penalty\",\n  \"penalties.amount\": \"Amount\",\n  \"rewards.title\": \"Rewards\",\n  \"rewards.customer_eth_share\": \"Staker ETH share\",\n  \"rewards.customer_keep_share\": \"Staker KEEP share\",\n  \"rewards.provider_eth_share\": \"Provider ETH share\",\n  \"rewards.provider_keep_share\": \"Provider KEEP share\",\n  \"rewards.customer_token_share\": \"Staker %v share\",\n  \"rewards.provider_token_share\": \"Provider %v share\",\n  \"rewards.customer_share_percentage\": \"Staker rewards % share\",\n  \"balances.title\": \"Balances\",\n  \"balances.beneficiary_keep\": \"KEEP rewards received by beneficiary\",\n  \"balances.beneficiary_eth\": \"ETH rewards withdrawn to beneficiary\",\n  \"balances.beneficiary_token\": \"%v rewards received by beneficiary\",\n  \"balances.operator_eth\": \"Operator ETH balance\",\n  \"balances.accumulated_rewards\": \"Accumulated ETH rewards\",\n  \"balances.beneficiary_raw_keep\": \"Beneficiary KEEP balance\",\n  \"balances.beneficiary_raw_eth\": \"Beneficiary ETH balance\",\n  \"balances.informational\": \"informational\",\n  \"ledger.title\": \"Beneficiary KEEP Ledger\",\n  \"ledger.opening_balance\": \"Balance at the beginning of the period\",\n  \"ledger.closing_balance\": \"Balance at the end of the period\",\n  \"ledger.transfers\": \"Transfers\",\n  \"ledger.block\": \"Block\",\n  \"ledger.counterparty\": \"Counterparty\",\n  \"ledger.amount\": \"Amount\",\n  \"ledger.balance\": \"Balance\",\n  \"groups.title\": \"Groups\",\n  \"groups.total\": \"The total number of groups created in the network\",\n  \"groups.active\": \"The number of active beacon groups in the network\",\n  \"groups.active_members\": \"The total number of your members in active groups\",\n  \"groups.inactive_members\": \"The total number of your members in no longer active groups\",\n  \"groups.unlocking_rewards\": \"Projected ETH rewards unlocking in the next %v days\",\n  \"operator_contracts.title\": \"Operator Contracts\",\n  \"operator_contracts.contract\": \"Contract\",\n  \"operator_contracts.address\": \"Address\",\n  \"operator_contracts.groups\": \"Groups\",\n  \"operator_contracts.active_groups\": \"Active groups\",\n  \"operator_contracts.active_members\": \"Your members in active groups\",\n  \"operator_contracts.inactive_members\": \"Your members in inactive groups\",\n  \"operator_contracts.accumulated_rewards\": \"Accumulated rewards\",\n  \"service_quality.title\": \"Service Quality\",\n  \"service_quality.requested\": \"Relay entries requested from your groups\",\n  \"service_quality.produced\": \"Relay entries produced by your groups\",\n  \"service_quality.timeouts\": \"Relay entry timeouts of your groups\",\n  \"service_quality.dkg_results\": \"DKG results submitted by your operator\",\n  \"gas_costs.title\": \"Operator Gas Costs\",\n  \"gas_costs.block\": \"Block\",\n  \"gas_costs.transaction\": \"Transaction\",\n  \"gas_costs.operation\": \"Operation\",\n  \"gas_costs.gas_used\": \"Gas used\",\n  \"gas_costs.gas_price\": \"Gas price\",\n  \"gas_costs.fee\": \"Fee\",\n  \"gas_costs.total\": \"Total gas costs\",\n  \"gas_costs.none\": \"Your operator sent no relay entries, DKG results or rewards withdrawals during the period.\",\n  \"gas_costs.operation.relay_entry\": \"Relay entry\",\n  \"gas_costs.operation.dkg_result\": \"DKG result\",\n  \"gas_costs.operation.rewards_withdrawal\": \"Rewards withdrawal\",\n  \"history.title\": \"Billing History\",\n  \"history.eth_rewards\": \"Your ETH rewards per period\",\n  \"history.keep_rewards\": \"Your KEEP rewards per period\",\n  \"history.stake\": \"Stake at period end (KEEP)\",\n  \"history.active_members\": \"Active group members at period end\",\n  \"active_groups.title\": \"Active Group Members\",\n  \"active_groups.contract\": \"Contract\",\n  \"active_groups.group\": \"Group\",\n  \"active_groups.members\": \"Members\",\n  \"active_groups.registration_block\": \"Registered at block\",\n  \"active_groups.stale_block\": \"Stale at block\",\n  \"active_groups.stale_date\": \"Estimated stale date\",\n  \"statement.title\": \"Annual Statement of Staking Rewards %v\",\n  \"statement.period\": \"Period: %v – %v, blocks %v – %v\",\n  \"statement.valuation\": \"Rewards are valued in %v at the token price of the day they were received.\",\n  \"statement.totals\": \"Totals\",\n  \"statement.receipts\": \"Rewards Received\",\n  \"statement.no_receipts\": \"No rewards were received during the year.\",\n  \"statement.date\": \"Date\",\n  \"statement.event\": \"Event\",\n  \"statement.block\": \"Block\",\n  \"statement.token\": \"Token\",\n  \"statement.amount\": \"Amount\",\n  \"statement.customer_share\": \"Your share\",\n  \"statement.price\": \"Price (%v)\",\n  \"statement.value\": \"Value (%v)\",\n  \"statement.customer_share_value\": \"Your share value (%v)\",\n  \"statement.total_value\": \"Total value\",\n  \"statement.kind.withdrawal\": \"ETH rewards withdrawal\",\n  \"statement.kind.distribution\": \"Reward distribution\",\n  \"statement.kind.group_expiry\": \"Group expiry\",\n  \"statement.unlocked\": \"Rewards Unlocked by Group Expiries\",\n  \"statement.unlocked_note\": \"ETH rewards of a group become withdrawable when the group expires and are received once withdrawn. They are listed for information only and are not included in the totals.\",\n  \"statement.unlocked_total\": \"Total unlocked\",\n  \"footer.attestation\": \"Figures of this billing are attested by the Ethereum account %v with the EIP-191 signature %v of the message:\"\n}\n",
+	"translations/pl.json":                     "{\n  \"number.thousands_separator\": \" \",\n  \"number.decimal_separator\": \",\",\n  \"header.title\": \"Raport stakingu Keep Random Beacon\",\n  \"header.issued_by\": \"Wystawiony przez\",\n  \"header.vat_id\": \"NIP\",\n  \"header.thank_you\": \"Dziękujemy za powierzenie nam Twoich KEEP ♥\",\n  \"header.reporting_period\": \"Okres rozliczeniowy: bloki %v – %v\",\n  \"staker.title\": \"Staker\",\n  \"staker.name\": \"Nazwa\",\n  \"staker.stake\": \"Stake\",\n  \"staker.operator\": \"Operator\",\n  \"staker.owner\": \"Właściciel\",\n  \"staker.beneficiary\": \"Beneficjent\",\n  \"delegation.title\": \"Delegacja\",\n  \"delegation.owner\": \"Właściciel\",\n  \"delegation.beneficiary\": \"Beneficjent\",\n  \"delegation.authorizer\": \"Autoryzujący\",\n  \"delegation.amount\": \"Delegowana kwota\",\n  \"delegation.created_at\": \"Utworzona\",\n  \"delegation.status\": \"Status\",\n  \"delegation.operator_contract_authorized\": \"Kontrakt operatora autoryzowany\",\n  \"delegation.locks\": \"Blokady\",\n  \"yes\": \"Tak\",\n  \"no\": \"Nie\",\n  \"delegation.not_locked\": \"Brak blokad\",\n  \"stake_changes.title\": \"Zmiany stake\",\n  \"stake_changes.period_start\": \"Stake na początku okresu (blok %v)\",\n  \"stake_changes.period_end\": \"Stake na końcu okresu (blok %v)\",\n  \"stake_changes.delta\": \"Zmiana stake\",\n  \"stake_changes.total_penalties\": \"Łącznie zredukowany i zajęty stake\",\n  \"penalties.title\": \"Kary\",\n  \"penalties.block\": \"Blok\",\n  \"penalties.transaction\": \"Transakcja\",\n  \"penalties.penalty\": \"Kara\",\n  \"penalties.amount\": \"Kwota\",\n  \"rewards.title\": \"Nagrody\",\n  \"rewards.customer_eth_share\": \"Udział stakera w ETH\",\n  \"rewards.customer_keep_share\": \"Udział stakera w KEEP\",\n  \"rewards.provider_eth_share\": \"Udział dostawcy w ETH\",\n  \"rewards.provider_keep_share\": \"Udział dostawcy w KEEP\",\n  \"rewards.customer_token_share\": \"Udział stakera w %v\",\n  \"rewards.provider_token_share\": \"Udział dostawcy w %v\",\n  \"rewards.customer_share_percentage\": \"Procentowy udział stakera w nagrodach\",\n  \"balances.title\": \"Salda\",\n  \"balances.beneficiary_keep\": \"Nagrody KEEP otrzymane przez beneficjenta\",\n  \"balances.beneficiary_eth\": \"Nagrody ETH wypłacone beneficjentowi\",\n  \"balances.beneficiary_token\": \"Nagrody %v otrzymane przez beneficjenta\",\n  \"balances.operator_eth\": \"Saldo ETH operatora\",\n  \"balances.accumulated_rewards\": \"Zgromadzone nagrody ETH\",\n  \"balances.beneficiary_raw_keep\": \"Saldo KEEP beneficjenta\",\n  \"balances.beneficiary_raw_eth\": \"Saldo ETH beneficjenta\",\n  \"balances.informational\": \"informacyjnie\",\n  \"ledger.title\": \"Rejestr KEEP beneficjenta\",\n  \"ledger.opening_balance\": \"Saldo na początku okresu\",\n  \"ledger.closing_balance\": \"Saldo na końcu okresu\",\n  \"ledger.transfers\": \"Transfery\",\n  \"ledger.block\": \"Blok\",\n  \"ledger.counterparty\": \"Kontrahent\",\n  \"ledger.amount\": \"Kwota\",\n  \"ledger.balance\": \"Saldo\",\n  \"groups.title\": \"Grupy\",\n  \"groups.total\": \"Łączna liczba grup utworzonych w sieci\",\n  \"groups.active\": \"Liczba aktywnych grup beacon w sieci\",\n  \"groups.active_members\": \"Łączna liczba Twoich członków w aktywnych grupach\",\n  \"groups.inactive_members\": \"Łączna liczba Twoich członków w nieaktywnych już grupach\",\n  \"groups.unlocking_rewards\": \"Przewidywane nagrody ETH odblokowane w ciągu najbliższych %v dni\",\n  \"operator_contracts.title\": \"Kontrakty operatora\",\n  \"operator_contracts.contract\": \"Kontrakt\",\n  \"operator_contracts.address\": \"Adres\",\n  \"operator_contracts.groups\": \"Grupy\",\n  \"operator_contracts.active_groups\": \"Aktywne grupy\",\n  \"operator_contracts.active_members\": \"Twoi członkowie w aktywnych grupach\",\n  \"operator_contracts.inactive_members\": \"Twoi członkowie w nieaktywnych grupach\",\n  \"operator_contracts.accumulated_rewards\": \"Zgromadzone nagrody\",\n  \"service_quality.title\": \"Jakość usług\",\n  \"service_quality.requested\": \"Wpisy relay zlecone Twoim grupom\",\n  \"service_quality.produced\": \"Wpisy relay wytworzone przez Twoje grupy\",\n  \"service_quality.timeouts\": \"Przekroczenia czasu wpisów relay Twoich grup\",\n  \"service_quality.dkg_results\": \"Wyniki DKG przesłane przez Twojego operatora\",\n  \"gas_costs.title\": \"Koszty gazu operatora\",\n  \"gas_costs.block\": \"Blok\",\n  \"gas_costs.transaction\": \"Transakcja\",\n  \"gas_costs.operation\": \"Operacja\",\n  \"gas_costs.gas_used\": \"Zużyty gaz\",\n  \"gas_costs.gas_price\": \"Cena gazu\",\n  \"gas_costs.fee\": \"Opłata\",\n  \"gas_costs.total\": \"Łączne koszty gazu\",\n  \"gas_costs.none\": \"Twój operator nie wysłał w okresie wpisów relay, wyników DKG ani wypłat nagród.\",\n  \"gas_costs.operation.relay_entry\": \"Wpis relay\",\n  \"gas_costs.operation.dkg_result\": \"Wynik DKG\",\n  \"gas_costs.operation.rewards_withdrawal\": \"Wypłata nagród\",\n  \"history.title\": \"Historia rozliczeń\",\n  \"history.eth_rewards\": \"Twoje nagrody ETH w okresach\",\n  \"history.keep_rewards\": \"Twoje nagrody KEEP w okresach\",\n  \"history.stake\": \"Stake na koniec okresu (KEEP)\",\n  \"history.active_members\": \"Członkowie aktywnych grup na koniec okresu\",\n  \"active_groups.title\": \"Członkowie aktywnych grup\",\n  \"active_groups.contract\": \"Kontrakt\",\n  \"active_groups.group\": \"Grupa\",\n  \"active_groups.members\": \"Członkowie\",\n  \"active_groups.registration_block\": \"Zarejestrowana w bloku\",\n  \"active_groups.stale_block\": \"Nieaktualna od bloku\",\n  \"active_groups.stale_date\": \"Przewidywana data wygaśnięcia\",\n  \"statement.title\": \"Roczne zestawienie nagród za staking %v\",\n  \"statement.period\": \"Okres: %v – %v, bloki %v – %v\",\n  \"statement.valuation\": \"Nagrody wyceniono w %v po kursie tokena z dnia ich otrzymania.\",\n  \"statement.totals\": \"Podsumowanie\",\n  \"statement.receipts\": \"Otrzymane nagrody\",\n  \"statement.no_receipts\": \"W ciągu roku nie otrzymano żadnych nagród.\",\n  \"statement.date\": \"Data\",\n  \"statement.event\": \"Zdarzenie\",\n  \"statement.block\": \"Blok\",\n  \"statement.token\": \"Token\",\n  \"statement.amount\": \"Kwota\",\n  \"statement.customer_share\": \"Twój udział\",\n  \"statement.price\": \"Kurs (%v)\",\n  \"statement.value\": \"Wartość (%v)\",\n  \"statement.customer_share_value\": \"Wartość Twojego udziału (%v)\",\n  \"statement.total_value\": \"Wartość łączna\",\n  \"statement.kind.withdrawal\": \"Wypłata nagród ETH\",\n  \"statement.kind.distribution\": \"Dystrybucja nagród\",\n  \"statement.kind.group_expiry\": \"Wygaśnięcie grupy\",\n  \"statement.unlocked\": \"Nagrody odblokowane przez wygaśnięcie grup\",\n  \"statement.unlocked_note\": \"Nagrody ETH grupy można wypłacić po jej wygaśnięciu, a otrzymuje się je z chwilą wypłaty. Podano je wyłącznie informacyjnie i nie są wliczone do podsumowania.\",\n  \"statement.unlocked_total\": \"Łącznie odblokowano\",\n  \"footer.attestation\": \"Dane tego rozliczenia są poświadczone przez konto Ethereum %v podpisem EIP-191 %v następującej wiadomości:\"\n}\n",
+}
@@ -0,0 +1,76 @@
+//go:build ignore
+// +build ignore
+
+// generate writes bundle.go with contents of the bundled directories.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// repositoryRoot is relative to the package directory where go generate
+// runs the generator.
+const repositoryRoot = "../.."
+
+var bundledDirectories = []string{"templates", "translations"}
+
+func main() {
+	files := make(map[string][]byte)
+
+	for _, directory := range bundledDirectories {
+		root := filepath.Join(repositoryRoot, directory)
+
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			name, err := filepath.Rel(repositoryRoot, path)
+			if err != nil {
+				return err
+			}
+
+			files[filepath.ToSlash(name)] = content
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("could not read bundled directory [%v]: [%v]", directory, err)
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	source := &bytes.Buffer{}
+	fmt.Fprintf(source, "// Code generated by go generate; DO NOT EDIT.\n\n")
+	fmt.Fprintf(source, "package assets\n\n")
+	fmt.Fprintf(source, "var files = map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(source, "%q: %q,\n", name, files[name])
+	}
+	fmt.Fprintf(source, "}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatalf("could not format bundle: [%v]", err)
+	}
+
+	if err := ioutil.WriteFile("bundle.go", formatted, 0644); err != nil {
+		log.Fatalf("could not write bundle: [%v]", err)
+	}
+}
@@ -1,71 +1,358 @@
 package billing
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"math/big"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/boar-network/keep-billings/pkg/chain"
 )
 
+// unlockingRewardsPeriod determines how far into the future the report
+// looks for active groups becoming stale when projecting rewards which
+// will become withdrawable.
+const unlockingRewardsPeriod = 30 * 24 * time.Hour
+
+// blockTimeSampleSize is the number of most recent blocks used to estimate
+// the average block time.
+const blockTimeSampleSize = 10000
+
 type BeaconReport struct {
 	*Report
 
+	OperatorContractsSummary []*OperatorContractSummary
+
+	TotalGroupsCount           int
+	ActiveGroupsCount          int
+	ActiveGroupsMembersCount   int
+	ActiveGroupsSummary        []*ActiveGroupSummary
+	InactiveGroupsMembersCount int
+
+	UnlockingRewardsDays int
+	UnlockingRewards     string
+
+	RelayEntriesRequestedCount int
+	RelayEntriesProducedCount  int
+	RelayEntryTimeoutsCount    int
+	DkgResultsSubmittedCount   int
+
+	GasCosts      []*GasCostSummary
+	TotalGasCosts string
+
+	// previous periods followed by this one, presented on charts only
+	History History `json:"-"`
+	// double-entry bookings of the period, exported to accounting systems
+	Journal []*JournalEntry `json:"-"`
+}
+
+// OperatorContractSummary presents the operator's groups and rewards of
+// a single operator contract. Contracts are labelled in the order they
+// were approved.
+type OperatorContractSummary struct {
+	Label                      string
+	Address                    string
 	TotalGroupsCount           int
 	ActiveGroupsCount          int
 	ActiveGroupsMembersCount   int
-	ActiveGroupsSummary        map[string]string
 	InactiveGroupsMembersCount int
+	AccumulatedRewards         string
+}
+
+// Operations of the operator whose transaction fees are summarized.
+const (
+	OperationRelayEntry        = "relay_entry"
+	OperationDkgResult         = "dkg_result"
+	OperationRewardsWithdrawal = "rewards_withdrawal"
+)
+
+// GasCostSummary is the fee of a transaction sent by the operator during
+// the period.
+type GasCostSummary struct {
+	BlockNumber uint64
+	TxHash      string
+	Operation   string
+	GasUsed     uint64
+	// gas price in gwei
+	GasPrice string
+	// fee in ETH
+	Fee string
+}
+
+type ActiveGroupSummary struct {
+	OperatorContract  string
+	PublicKey         string
+	Members           string
+	RegistrationBlock uint64
+	StaleBlock        uint64
+	StaleDate         string
 }
 
 type BeaconDataSource interface {
 	DataSource
 
-	AllGroupsCount() (int64, error)
-	ActiveGroupsCount() (int64, error)
-	FirstActiveGroupIndex() (int64, error)
-	GroupPublicKey(index int64) ([]byte, error)
-	GroupMembers(groupPublicKey []byte) (map[int]string, error)
-	GroupMemberRewards(groupPublicKey []byte) (*big.Int, error)
-	AreRewardsWithdrawn(operator string, groupIndex int64) (bool, error)
+	// OperatorContracts returns all beacon operator contracts, ordered
+	// from the oldest one. All group related methods take one of them.
+	OperatorContracts() []string
+
+	AllGroupsCount(ctx context.Context, operatorContract string) (int64, error)
+	ActiveGroupsCount(
+		ctx context.Context,
+		operatorContract string,
+	) (int64, error)
+	FirstActiveGroupIndex(
+		ctx context.Context,
+		operatorContract string,
+	) (int64, error)
+	GroupPublicKey(
+		ctx context.Context,
+		operatorContract string,
+		index int64,
+	) ([]byte, error)
+	GroupRegistrationBlock(
+		ctx context.Context,
+		operatorContract string,
+		index int64,
+	) (uint64, error)
+	GroupLifetime(ctx context.Context, operatorContract string) (uint64, error)
+	GroupMembers(
+		ctx context.Context,
+		operatorContract string,
+		groupPublicKey []byte,
+	) (map[int]string, error)
+	GroupMemberRewards(
+		ctx context.Context,
+		operatorContract string,
+		groupPublicKey []byte,
+	) (*big.Int, error)
+	AreRewardsWithdrawn(
+		ctx context.Context,
+		operatorContract string,
+		operator string,
+		groupIndex int64,
+	) (bool, error)
+	RelayEntryEvents(
+		ctx context.Context,
+		operatorContract string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.RelayEntryEvent, error)
+	DkgResultSubmissions(
+		ctx context.Context,
+		operatorContract string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.DkgResultSubmission, error)
+	RewardsWithdrawals(
+		ctx context.Context,
+		operatorContract string,
+		beneficiary string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.RewardsWithdrawal, error)
+	TransactionCost(ctx context.Context, txHash string) (*chain.TransactionCost, error)
 }
 
 type group struct {
-	index     int64
-	isActive  bool
-	publicKey []byte
-	members   map[int]string
+	operatorContract  string
+	index             int64
+	isActive          bool
+	publicKey         []byte
+	members           map[int]string
+	registrationBlock uint64
+	staleBlock        uint64
 }
 
 type BeaconReportGenerator struct {
 	dataSource BeaconDataSource
 
-	groups []*group
+	period                 *Period
+	keepRewardDistributors []string
+	rewardTokens           []*RewardToken
+
+	periodEndBlockTime time.Time
+	averageBlockTime   time.Duration
+
+	operatorContracts    []string
+	groups               []*group
+	relayEntryEvents     []*chain.RelayEntryEvent
+	dkgResultSubmissions []*chain.DkgResultSubmission
 }
 
 func NewBeaconReportGenerator(
 	dataSource BeaconDataSource,
+	period *Period,
+	keepRewardDistributors []string,
+	rewardTokens []*RewardToken,
 ) *BeaconReportGenerator {
+	if len(keepRewardDistributors) == 0 {
+		logger.Warnf(
+			"no KEEP reward distributors configured; " +
+				"customer KEEP shares will be zero",
+		)
+	}
+
 	return &BeaconReportGenerator{
-		dataSource: dataSource,
+		dataSource:             dataSource,
+		period:                 period,
+		keepRewardDistributors: keepRewardDistributors,
+		rewardTokens:           rewardTokens,
 	}
 }
 
-func (brg *BeaconReportGenerator) FetchCommonData() error {
+func (brg *BeaconReportGenerator) FetchCommonData(ctx context.Context) error {
 	var err error
 
-	brg.groups, err = brg.fetchGroupsData()
+	if err := brg.resolvePeriod(ctx); err != nil {
+		return err
+	}
+
+	brg.periodEndBlockTime, err = brg.dataSource.BlockTime(ctx, brg.period.EndBlock)
+	if err != nil {
+		return fmt.Errorf(
+			"could not get time of block [%v]: [%v]",
+			brg.period.EndBlock,
+			err,
+		)
+	}
+
+	brg.averageBlockTime, err = brg.fetchAverageBlockTime(ctx)
 	if err != nil {
 		return err
 	}
 
+	brg.operatorContracts = brg.dataSource.OperatorContracts()
+	brg.groups = make([]*group, 0)
+	brg.relayEntryEvents = make([]*chain.RelayEntryEvent, 0)
+	brg.dkgResultSubmissions = make([]*chain.DkgResultSubmission, 0)
+
+	for _, operatorContract := range brg.operatorContracts {
+		if err := brg.fetchOperatorContractData(ctx, operatorContract); err != nil {
+			return fmt.Errorf(
+				"could not get data of operator contract [%v]: [%v]",
+				operatorContract,
+				err,
+			)
+		}
+	}
+
 	return nil
 }
 
-func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
-	numberOfAllGroups, err := brg.dataSource.AllGroupsCount()
+func (brg *BeaconReportGenerator) fetchOperatorContractData(
+	ctx context.Context,
+	operatorContract string,
+) error {
+	groups, err := brg.fetchGroupsData(ctx, operatorContract)
+	if err != nil {
+		return err
+	}
+	brg.groups = append(brg.groups, groups...)
+
+	relayEntryEvents, err := brg.dataSource.RelayEntryEvents(
+		ctx,
+		operatorContract,
+		brg.period.StartBlock,
+		brg.period.EndBlock,
+	)
+	if err != nil {
+		return fmt.Errorf("could not get relay entry events: [%v]", err)
+	}
+	brg.relayEntryEvents = append(brg.relayEntryEvents, relayEntryEvents...)
+
+	dkgResultSubmissions, err := brg.dataSource.DkgResultSubmissions(
+		ctx,
+		operatorContract,
+		brg.period.StartBlock,
+		brg.period.EndBlock,
+	)
+	if err != nil {
+		return fmt.Errorf("could not get DKG result submissions: [%v]", err)
+	}
+	brg.dkgResultSubmissions = append(
+		brg.dkgResultSubmissions,
+		dkgResultSubmissions...,
+	)
+
+	return nil
+}
+
+func (brg *BeaconReportGenerator) resolvePeriod(ctx context.Context) error {
+	currentBlock, err := brg.dataSource.CurrentBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get current block: [%v]", err)
+	}
+
+	if brg.period.EndBlock == 0 {
+		brg.period.EndBlock = currentBlock
+	}
+
+	if brg.period.EndBlock > currentBlock {
+		return fmt.Errorf(
+			"period end block [%v] is not confirmed yet; "+
+				"most recent confirmed block is [%v]",
+			brg.period.EndBlock,
+			currentBlock,
+		)
+	}
+
+	if brg.period.StartBlock > brg.period.EndBlock {
+		return fmt.Errorf(
+			"period start block [%v] is after period end block [%v]",
+			brg.period.StartBlock,
+			brg.period.EndBlock,
+		)
+	}
+
+	logger.Infof(
+		"reporting period covers blocks [%v - %v]",
+		brg.period.StartBlock,
+		brg.period.EndBlock,
+	)
+
+	// read the whole report state as of the period end so data which
+	// can still be reorganised away never makes it to the report
+	brg.dataSource.PinBlock(brg.period.EndBlock)
+
+	return nil
+}
+
+func (brg *BeaconReportGenerator) fetchAverageBlockTime(
+	ctx context.Context,
+) (time.Duration, error) {
+	if brg.period.EndBlock == 0 {
+		return 0, fmt.Errorf("could not estimate block time at genesis")
+	}
+
+	sampleStartBlock := uint64(0)
+	if brg.period.EndBlock > blockTimeSampleSize {
+		sampleStartBlock = brg.period.EndBlock - blockTimeSampleSize
+	}
+
+	sampleStartTime, err := brg.dataSource.BlockTime(ctx, sampleStartBlock)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"could not get time of block [%v]: [%v]",
+			sampleStartBlock,
+			err,
+		)
+	}
+
+	sampleDuration := brg.periodEndBlockTime.Sub(sampleStartTime)
+	sampleBlocks := int64(brg.period.EndBlock - sampleStartBlock)
+
+	return sampleDuration / time.Duration(sampleBlocks), nil
+}
+
+func (brg *BeaconReportGenerator) fetchGroupsData(
+	ctx context.Context,
+	operatorContract string,
+) ([]*group, error) {
+	numberOfAllGroups, err := brg.dataSource.AllGroupsCount(ctx, operatorContract)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"could not get total group count: [%v]",
@@ -73,7 +360,10 @@ func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
 		)
 	}
 
-	firstActiveGroupIndex, err := brg.dataSource.FirstActiveGroupIndex()
+	firstActiveGroupIndex, err := brg.dataSource.FirstActiveGroupIndex(
+		ctx,
+		operatorContract,
+	)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"could not get first active group index: [%v]",
@@ -81,10 +371,22 @@ func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
 		)
 	}
 
+	groupLifetime, err := brg.dataSource.GroupLifetime(ctx, operatorContract)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not get group lifetime: [%v]",
+			err,
+		)
+	}
+
 	groups := make([]*group, 0)
 
 	for index := int64(0); index < numberOfAllGroups; index++ {
-		publicKey, err := brg.dataSource.GroupPublicKey(index)
+		publicKey, err := brg.dataSource.GroupPublicKey(
+			ctx,
+			operatorContract,
+			index,
+		)
 		if err != nil {
 			return nil, fmt.Errorf(
 				"could not get public key of group with index [%v]: [%v]",
@@ -93,7 +395,11 @@ func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
 			)
 		}
 
-		members, err := brg.dataSource.GroupMembers(publicKey)
+		members, err := brg.dataSource.GroupMembers(
+			ctx,
+			operatorContract,
+			publicKey,
+		)
 		if err != nil {
 			return nil, fmt.Errorf(
 				"could not get members of group with index [%v]: [%v]",
@@ -102,6 +408,19 @@ func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
 			)
 		}
 
+		registrationBlock, err := brg.dataSource.GroupRegistrationBlock(
+			ctx,
+			operatorContract,
+			index,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not get registration block of group with index [%v]: [%v]",
+				index,
+				err,
+			)
+		}
+
 		isActive := false
 		if index >= firstActiveGroupIndex {
 			isActive = true
@@ -110,10 +429,13 @@ func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
 		groups = append(
 			groups,
 			&group{
-				index:     index,
-				isActive:  isActive,
-				publicKey: publicKey,
-				members:   members,
+				operatorContract:  operatorContract,
+				index:             index,
+				isActive:          isActive,
+				publicKey:         publicKey,
+				members:           members,
+				registrationBlock: registrationBlock,
+				staleBlock:        registrationBlock + groupLifetime,
 			},
 		)
 	}
@@ -122,29 +444,59 @@ func (brg *BeaconReportGenerator) fetchGroupsData() ([]*group, error) {
 }
 
 func (brg *BeaconReportGenerator) Generate(
+	ctx context.Context,
 	customer *Customer,
 ) (*BeaconReport, error) {
-	stake, err := brg.dataSource.Stake(customer.Operator)
+	delegation, err := brg.dataSource.Delegation(ctx, customer.Operator)
+	if err != nil {
+		return nil, fmt.Errorf("could not get delegation info: [%v]", err)
+	}
+
+	if err := resolveCustomerAddresses(customer, delegation); err != nil {
+		return nil, err
+	}
+
+	stake, err := brg.dataSource.Stake(ctx, customer.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	operatorEthBalance, err := brg.dataSource.EthBalance(ctx, customer.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiaryRawEthBalance, err := brg.dataSource.EthBalance(ctx, customer.Beneficiary)
 	if err != nil {
 		return nil, err
 	}
 
-	operatorEthBalance, err := brg.dataSource.EthBalance(customer.Operator)
+	beneficiaryRawKeepBalance, err := brg.dataSource.KeepBalance(ctx, customer.Beneficiary)
 	if err != nil {
 		return nil, err
 	}
 
-	beneficiaryEthBalance, err := brg.dataSource.EthBalance(customer.Beneficiary)
+	beneficiaryEthBalance, withdrawals, err := brg.calculateWithdrawnRewards(
+		ctx,
+		customer,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	beneficiaryKeepBalance, err := brg.dataSource.KeepBalance(customer.Beneficiary)
+	beneficiaryKeepBalance, err := calculateReceivedKeepRewards(
+		ctx,
+		brg.dataSource,
+		customer.Beneficiary,
+		brg.keepRewardDistributors,
+		brg.period,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	accumulatedEthRewards, err := brg.calculateAccumulatedRewards(customer.Operator)
+	accumulatedEthRewards, contractsAccumulatedEthRewards, err :=
+		brg.calculateAccumulatedRewards(ctx, customer.Operator)
 	if err != nil {
 		return nil, err
 	}
@@ -158,17 +510,64 @@ func (brg *BeaconReportGenerator) Generate(
 			accumulatedEthRewards,
 		)
 
+	customerReceivedEthShare, providerReceivedEthShare := splitRewards(
+		big.NewFloat(float64(customer.CustomerSharePercentage)),
+		beneficiaryEthBalance,
+	)
+
 	baseReport := &Report{
-		Customer:               customer,
-		Stake:                  stake.Text('f', 0),
-		OperatorBalance:        operatorEthBalance.Text('f', 6),
-		BeneficiaryEthBalance:  beneficiaryEthBalance.Text('f', 6),
-		BeneficiaryKeepBalance: beneficiaryKeepBalance.Text('f', 6),
-		AccumulatedRewards:     accumulatedEthRewards.Text('f', 6),
-		CustomerEthShare:       customerEthRewardsShare.Text('f', 6),
-		ProviderEthShare:       providerEthRewardsShare.Text('f', 6),
-		CustomerKeepShare:      customerKeepRewardsShare.Text('f', 6),
-		ProviderKeepShare:      providerKeepRewardsShare.Text('f', 6),
+		Customer:                  customer,
+		PeriodStartBlock:          brg.period.StartBlock,
+		PeriodEndBlock:            brg.period.EndBlock,
+		PeriodEndDate:             formatTime(brg.periodEndBlockTime),
+		Stake:                     stake.Text('f', 0),
+		OperatorBalance:           operatorEthBalance.Text('f', 6),
+		BeneficiaryEthBalance:     beneficiaryEthBalance.Text('f', 6),
+		BeneficiaryKeepBalance:    beneficiaryKeepBalance.Text('f', 6),
+		BeneficiaryRawEthBalance:  beneficiaryRawEthBalance.Text('f', 6),
+		BeneficiaryRawKeepBalance: beneficiaryRawKeepBalance.Text('f', 6),
+		AccumulatedRewards:        accumulatedEthRewards.Text('f', 6),
+		CustomerEthShare:          customerEthRewardsShare.Text('f', 6),
+		ProviderEthShare:          providerEthRewardsShare.Text('f', 6),
+		CustomerKeepShare:         customerKeepRewardsShare.Text('f', 6),
+		ProviderKeepShare:         providerKeepRewardsShare.Text('f', 6),
+		CustomerReceivedEthShare:  customerReceivedEthShare.Text('f', 6),
+		ProviderReceivedEthShare:  providerReceivedEthShare.Text('f', 6),
+	}
+
+	err = summarizeStakeChanges(
+		ctx,
+		brg.dataSource,
+		customer.Operator,
+		brg.period,
+		baseReport,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summarizeDelegation(delegation, baseReport)
+
+	baseReport.TokenRewards, err = calculateTokenRewards(
+		ctx,
+		brg.dataSource,
+		customer,
+		brg.rewardTokens,
+		brg.period,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = buildKeepLedger(
+		ctx,
+		brg.dataSource,
+		customer.Beneficiary,
+		brg.period,
+		baseReport,
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	activeGroupsMemberCount, inactiveGroupsMemberCount,
@@ -176,13 +575,50 @@ func (brg *BeaconReportGenerator) Generate(
 		customer.Operator,
 	)
 
+	unlockingEthRewards, err := brg.calculateUnlockingRewards(
+		ctx,
+		customer.Operator,
+		unlockingRewardsPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	relayEntriesRequested, relayEntriesProduced, relayEntryTimeouts :=
+		brg.summarizeRelayEntries(customer.Operator)
+
+	dkgResultsSubmitted := brg.summarizeDkgResultSubmissions(customer.Operator)
+
+	operatorContractsSummary := brg.summarizeOperatorContracts(
+		customer.Operator,
+		contractsAccumulatedEthRewards,
+	)
+
+	gasCosts, totalGasCosts, err := brg.calculateGasCosts(
+		ctx,
+		customer.Operator,
+		withdrawals,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &BeaconReport{
 		Report:                     baseReport,
+		OperatorContractsSummary:   operatorContractsSummary,
 		TotalGroupsCount:           len(brg.groups),
 		ActiveGroupsCount:          len(activeGroupsSummary),
 		ActiveGroupsMembersCount:   activeGroupsMemberCount,
 		ActiveGroupsSummary:        activeGroupsSummary,
 		InactiveGroupsMembersCount: inactiveGroupsMemberCount,
+		UnlockingRewardsDays:       int(unlockingRewardsPeriod.Hours() / 24),
+		UnlockingRewards:           unlockingEthRewards.Text('f', 6),
+		RelayEntriesRequestedCount: relayEntriesRequested,
+		RelayEntriesProducedCount:  relayEntriesProduced,
+		RelayEntryTimeoutsCount:    relayEntryTimeouts,
+		DkgResultsSubmittedCount:   dkgResultsSubmitted,
+		GasCosts:                   gasCosts,
+		TotalGasCosts:              totalGasCosts.Text('f', 6),
 	}, nil
 }
 
@@ -194,12 +630,12 @@ func (brg *BeaconReportGenerator) summarizeGroupsInfo(
 	// count of members for the operator in no longer active groups
 	inactiveGroupsMemberCount int,
 	// summary of all active groups, no matter if the operator has a member
-	// in a group or not
-	activeGroupsSummary map[string]string,
+	// in a group or not, ordered by group index
+	activeGroupsSummary []*ActiveGroupSummary,
 ) {
 	activeGroupsMemberCount = 0
 	inactiveGroupsMemberCount = 0
-	activeGroupsSummary = make(map[string]string)
+	activeGroupsSummary = make([]*ActiveGroupSummary, 0)
 
 	for _, group := range brg.groups {
 		operatorMembers := getGroupMemberIndexes(operator, group)
@@ -224,9 +660,24 @@ func (brg *BeaconReportGenerator) summarizeGroupsInfo(
 			operatorMembersString = "-"
 		}
 
-		group := "0x" + hex.EncodeToString(group.publicKey)[:32] + "..."
+		staleTime := estimateBlockTime(
+			group.staleBlock,
+			brg.period.EndBlock,
+			brg.periodEndBlockTime,
+			brg.averageBlockTime,
+		)
 
-		activeGroupsSummary[group] = operatorMembersString
+		activeGroupsSummary = append(
+			activeGroupsSummary,
+			&ActiveGroupSummary{
+				OperatorContract:  brg.operatorContractLabel(group.operatorContract),
+				PublicKey:         "0x" + hex.EncodeToString(group.publicKey)[:32] + "...",
+				Members:           operatorMembersString,
+				RegistrationBlock: group.registrationBlock,
+				StaleBlock:        group.staleBlock,
+				StaleDate:         formatTime(staleTime),
+			},
+		)
 	}
 
 	return
@@ -244,47 +695,421 @@ func getGroupMemberIndexes(operatorAddress string, _group *group) []int {
 	return operatorMembers
 }
 
+// calculateAccumulatedRewards sums up rewards of the operator's members in
+// no longer active groups which were not withdrawn yet. Returns the total
+// and the amounts in wei per operator contract.
 func (brg *BeaconReportGenerator) calculateAccumulatedRewards(
+	ctx context.Context,
 	operator string,
-) (*big.Float, error) {
+) (*big.Float, map[string]*big.Int, error) {
 	accumulatedRewardsWei := big.NewInt(0)
+	contractsRewardsWei := make(map[string]*big.Int)
 
 	for _, group := range brg.groups {
+		if group.isActive {
+			continue
+		}
+
+		operatorMembers := getGroupMemberIndexes(operator, group)
+		if len(operatorMembers) == 0 {
+			continue
+		}
+
 		rewardsWithdrawn, err := brg.dataSource.AreRewardsWithdrawn(
+			ctx,
+			group.operatorContract,
 			operator,
 			group.index,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		if rewardsWithdrawn {
 			continue
 		}
 
-		if group.isActive {
-			continue
+		memberRewards, err := brg.dataSource.GroupMemberRewards(
+			ctx,
+			group.operatorContract,
+			group.publicKey,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		groupRewardsWei := new(big.Int).Mul(
+			memberRewards,
+			big.NewInt(int64(len(operatorMembers))),
+		)
+
+		accumulatedRewardsWei = new(big.Int).Add(
+			accumulatedRewardsWei,
+			groupRewardsWei,
+		)
+
+		contractRewardsWei, ok := contractsRewardsWei[group.operatorContract]
+		if !ok {
+			contractRewardsWei = big.NewInt(0)
+		}
+		contractsRewardsWei[group.operatorContract] = new(big.Int).Add(
+			contractRewardsWei,
+			groupRewardsWei,
+		)
+	}
+
+	return chain.WeiToEth(accumulatedRewardsWei), contractsRewardsWei, nil
+}
+
+// summarizeOperatorContracts presents the operator's groups and accumulated
+// rewards separately for each operator contract.
+func (brg *BeaconReportGenerator) summarizeOperatorContracts(
+	operator string,
+	contractsAccumulatedRewardsWei map[string]*big.Int,
+) []*OperatorContractSummary {
+	summaries := make([]*OperatorContractSummary, 0)
+
+	for _, operatorContract := range brg.operatorContracts {
+		summary := &OperatorContractSummary{
+			Label:              brg.operatorContractLabel(operatorContract),
+			Address:            operatorContract,
+			AccumulatedRewards: big.NewFloat(0).Text('f', 6),
+		}
+
+		for _, group := range brg.groups {
+			if group.operatorContract != operatorContract {
+				continue
+			}
+
+			summary.TotalGroupsCount++
+
+			operatorMembersCount := len(getGroupMemberIndexes(operator, group))
+			if group.isActive {
+				summary.ActiveGroupsCount++
+				summary.ActiveGroupsMembersCount += operatorMembersCount
+			} else {
+				summary.InactiveGroupsMembersCount += operatorMembersCount
+			}
 		}
 
-		memberRewards, err := brg.dataSource.GroupMemberRewards(group.publicKey)
+		if rewardsWei, ok := contractsAccumulatedRewardsWei[operatorContract]; ok {
+			summary.AccumulatedRewards = chain.WeiToEth(rewardsWei).Text('f', 6)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
+
+// operatorContractLabel returns the label of the operator contract based on
+// its position in the approval order, the oldest contract being the first.
+func (brg *BeaconReportGenerator) operatorContractLabel(
+	operatorContract string,
+) string {
+	for i, address := range brg.operatorContracts {
+		if address == operatorContract {
+			return fmt.Sprintf("#%v", i+1)
+		}
+	}
+
+	return operatorContract
+}
+
+// calculateWithdrawnRewards sums up the operator's group member rewards
+// withdrawn to the customer's beneficiary during the reporting period.
+// Other ETH held by the beneficiary is not attributed to staking. Returns
+// the sum along with the withdrawals.
+func (brg *BeaconReportGenerator) calculateWithdrawnRewards(
+	ctx context.Context,
+	customer *Customer,
+) (*big.Float, []*chain.RewardsWithdrawal, error) {
+	withdrawnRewards := big.NewFloat(0)
+	operatorWithdrawals := make([]*chain.RewardsWithdrawal, 0)
+
+	for _, operatorContract := range brg.operatorContracts {
+		withdrawals, err := brg.dataSource.RewardsWithdrawals(
+			ctx,
+			operatorContract,
+			customer.Beneficiary,
+			brg.period.StartBlock,
+			brg.period.EndBlock,
+		)
 		if err != nil {
-			return nil, err
+			return nil, nil, fmt.Errorf(
+				"could not get rewards withdrawals: [%v]",
+				err,
+			)
+		}
+
+		for _, withdrawal := range withdrawals {
+			if !strings.EqualFold(withdrawal.Operator, customer.Operator) {
+				continue
+			}
+
+			withdrawnRewards = new(big.Float).Add(
+				withdrawnRewards,
+				withdrawal.Amount,
+			)
+			operatorWithdrawals = append(operatorWithdrawals, withdrawal)
+		}
+	}
+
+	return withdrawnRewards, operatorWithdrawals, nil
+}
+
+// calculateUnlockingRewards projects the rewards of the operator's members
+// in active groups which become stale within the given period from the end
+// of the reporting period.
+// Rewards of a group can be withdrawn only once the group is stale.
+func (brg *BeaconReportGenerator) calculateUnlockingRewards(
+	ctx context.Context,
+	operator string,
+	period time.Duration,
+) (*big.Float, error) {
+	unlockingRewardsWei := big.NewInt(0)
+
+	unlockingEndBlock := brg.period.EndBlock
+	if brg.averageBlockTime > 0 {
+		unlockingEndBlock += uint64(period / brg.averageBlockTime)
+	}
+
+	for _, group := range brg.groups {
+		if !group.isActive || group.staleBlock > unlockingEndBlock {
+			continue
 		}
 
 		operatorMembers := getGroupMemberIndexes(operator, group)
+		if len(operatorMembers) == 0 {
+			continue
+		}
+
+		memberRewards, err := brg.dataSource.GroupMemberRewards(
+			ctx,
+			group.operatorContract,
+			group.publicKey,
+		)
+		if err != nil {
+			return nil, err
+		}
 
 		groupRewardsWei := new(big.Int).Mul(
 			memberRewards,
 			big.NewInt(int64(len(operatorMembers))),
 		)
 
-		accumulatedRewardsWei = new(big.Int).Add(
-			accumulatedRewardsWei,
+		unlockingRewardsWei = new(big.Int).Add(
+			unlockingRewardsWei,
 			groupRewardsWei,
 		)
 	}
 
-	return chain.WeiToEth(accumulatedRewardsWei), nil
+	return chain.WeiToEth(unlockingRewardsWei), nil
+}
+
+// summarizeRelayEntries counts relay entries requested from groups the
+// operator is a member of during the reporting period and how many of them
+// were produced or timed out. Each operator contract processes its relay
+// entries independently.
+func (brg *BeaconReportGenerator) summarizeRelayEntries(
+	operator string,
+) (requested int, produced int, timedOut int) {
+	currentRequestGroups := make(map[string]*group)
+
+	for _, event := range brg.relayEntryEvents {
+		contract := event.OperatorContract
+
+		switch event.Type {
+		case chain.RelayEntryRequested:
+			currentRequestGroups[contract] = brg.findGroupByPublicKey(
+				contract,
+				event.GroupPublicKey,
+			)
+			if isOperatorGroup(operator, currentRequestGroups[contract]) {
+				requested++
+			}
+		case chain.RelayEntrySubmitted:
+			if isOperatorGroup(operator, currentRequestGroups[contract]) {
+				produced++
+			}
+			delete(currentRequestGroups, contract)
+		case chain.RelayEntryTimedOut:
+			timedOutGroup := brg.findGroupByIndex(contract, event.GroupIndex)
+			if isOperatorGroup(operator, timedOutGroup) {
+				timedOut++
+			}
+			delete(currentRequestGroups, contract)
+		}
+	}
+
+	return
+}
+
+// summarizeDkgResultSubmissions counts DKG results submitted by the operator
+// during the reporting period.
+func (brg *BeaconReportGenerator) summarizeDkgResultSubmissions(
+	operator string,
+) int {
+	submitted := 0
+
+	for _, submission := range brg.dkgResultSubmissions {
+		group := brg.findGroupByPublicKey(
+			submission.OperatorContract,
+			submission.GroupPublicKey,
+		)
+		if group == nil {
+			continue
+		}
+
+		submitter, ok := group.members[submission.MemberIndex]
+		if ok && strings.ToLower(submitter) == strings.ToLower(operator) {
+			submitted++
+		}
+	}
+
+	return submitted
+}
+
+// calculateGasCosts sums up fees of transactions sent by the operator during
+// the reporting period: relay entries and DKG results submitted for the
+// operator's groups and rewards withdrawals of the operator. Transactions
+// not emitting any beacon event, like ticket submissions, can't be found
+// and are not included.
+func (brg *BeaconReportGenerator) calculateGasCosts(
+	ctx context.Context,
+	operator string,
+	withdrawals []*chain.RewardsWithdrawal,
+) ([]*GasCostSummary, *big.Float, error) {
+	operations := make(map[string]string)
+	txHashes := make([]string, 0)
+
+	addTransaction := func(txHash string, operation string) {
+		if _, ok := operations[txHash]; ok {
+			return
+		}
+		operations[txHash] = operation
+		txHashes = append(txHashes, txHash)
+	}
+
+	currentRequestGroups := make(map[string]*group)
+	for _, event := range brg.relayEntryEvents {
+		contract := event.OperatorContract
+
+		switch event.Type {
+		case chain.RelayEntryRequested:
+			currentRequestGroups[contract] = brg.findGroupByPublicKey(
+				contract,
+				event.GroupPublicKey,
+			)
+		case chain.RelayEntrySubmitted:
+			if isOperatorGroup(operator, currentRequestGroups[contract]) {
+				addTransaction(event.TxHash, OperationRelayEntry)
+			}
+			delete(currentRequestGroups, contract)
+		case chain.RelayEntryTimedOut:
+			delete(currentRequestGroups, contract)
+		}
+	}
+
+	for _, submission := range brg.dkgResultSubmissions {
+		group := brg.findGroupByPublicKey(
+			submission.OperatorContract,
+			submission.GroupPublicKey,
+		)
+		if isOperatorGroup(operator, group) {
+			addTransaction(submission.TxHash, OperationDkgResult)
+		}
+	}
+
+	for _, withdrawal := range withdrawals {
+		addTransaction(withdrawal.TxHash, OperationRewardsWithdrawal)
+	}
+
+	gasCosts := make([]*GasCostSummary, 0)
+	totalGasCosts := big.NewFloat(0)
+
+	for _, txHash := range txHashes {
+		cost, err := brg.dataSource.TransactionCost(ctx, txHash)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"could not get cost of transaction [%v]: [%v]",
+				txHash,
+				err,
+			)
+		}
+
+		// any group member can submit, only the operator's fees count
+		if !strings.EqualFold(cost.From, operator) {
+			continue
+		}
+
+		gasCosts = append(gasCosts, &GasCostSummary{
+			BlockNumber: cost.BlockNumber,
+			TxHash:      cost.TxHash,
+			Operation:   operations[txHash],
+			GasUsed:     cost.GasUsed,
+			GasPrice:    chain.WeiToGwei(cost.GasPrice).Text('f', 2),
+			Fee:         cost.Fee.Text('f', 6),
+		})
+		totalGasCosts = new(big.Float).Add(totalGasCosts, cost.Fee)
+	}
+
+	sort.SliceStable(gasCosts, func(i, j int) bool {
+		return gasCosts[i].BlockNumber < gasCosts[j].BlockNumber
+	})
+
+	return gasCosts, totalGasCosts, nil
+}
+
+func (brg *BeaconReportGenerator) findGroupByPublicKey(
+	operatorContract string,
+	publicKey []byte,
+) *group {
+	for _, group := range brg.groups {
+		if group.operatorContract == operatorContract &&
+			bytes.Equal(group.publicKey, publicKey) {
+			return group
+		}
+	}
+
+	return nil
+}
+
+func (brg *BeaconReportGenerator) findGroupByIndex(
+	operatorContract string,
+	index int64,
+) *group {
+	for _, group := range brg.groups {
+		if group.operatorContract == operatorContract && group.index == index {
+			return group
+		}
+	}
+
+	return nil
+}
+
+func isOperatorGroup(operator string, _group *group) bool {
+	return _group != nil && len(getGroupMemberIndexes(operator, _group)) > 0
+}
+
+// estimateBlockTime estimates the time of the given block based on the time
+// of a reference block and the average block time. The estimated block may
+// be both before and after the reference block.
+func estimateBlockTime(
+	block uint64,
+	referenceBlock uint64,
+	referenceBlockTime time.Time,
+	averageBlockTime time.Duration,
+) time.Time {
+	if block >= referenceBlock {
+		return referenceBlockTime.Add(
+			time.Duration(block-referenceBlock) * averageBlockTime,
+		)
+	}
+
+	return referenceBlockTime.Add(
+		-time.Duration(referenceBlock-block) * averageBlockTime,
+	)
 }
 
 func calculateFinalBeaconRewards(
@@ -298,28 +1123,20 @@ func calculateFinalBeaconRewards(
 	customerKeepRewardShare *big.Float,
 	providerKeepRewardShare *big.Float,
 ) {
-	customerKeepRewardShare = new(big.Float).Quo(
-		new(big.Float).Mul(beneficiaryKeepBalance, customerSharePercentage),
-		big.NewFloat(100),
-	)
-	providerKeepRewardShare = new(big.Float).Sub(
+	customerKeepRewardShare, providerKeepRewardShare = splitRewards(
+		customerSharePercentage,
 		beneficiaryKeepBalance,
-		customerKeepRewardShare,
 	)
 
-	customerAccumulatedEthRewardShare := new(big.Float).Quo(
-		new(big.Float).Mul(accumulatedEthRewards, customerSharePercentage),
-		big.NewFloat(100),
+	var customerAccumulatedEthRewardShare *big.Float
+	customerAccumulatedEthRewardShare, providerEthRewardShare = splitRewards(
+		customerSharePercentage,
+		accumulatedEthRewards,
 	)
 
 	customerEthRewardShare = new(big.Float).Add(
 		customerAccumulatedEthRewardShare, beneficiaryEthBalance,
 	)
 
-	providerEthRewardShare = new(big.Float).Sub(
-		accumulatedEthRewards,
-		customerAccumulatedEthRewardShare,
-	)
-
 	return
 }
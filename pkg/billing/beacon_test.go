@@ -1,8 +1,12 @@
 package billing
 
 import (
+	"context"
 	"math/big"
 	"testing"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/chain"
 )
 
 func TestCalculateBeaconRewards(t *testing.T) {
@@ -129,3 +133,271 @@ func TestCalculateBeaconRewards(t *testing.T) {
 		})
 	}
 }
+
+func TestEstimateBlockTime(t *testing.T) {
+	referenceBlockTime := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		block            uint64
+		referenceBlock   uint64
+		averageBlockTime time.Duration
+
+		expectedBlockTime time.Time
+	}{
+		"future block": {
+			block:            10100,
+			referenceBlock:   10000,
+			averageBlockTime: 15 * time.Second,
+
+			// 100 blocks * 15s = 25min
+			expectedBlockTime: time.Date(2020, 5, 10, 12, 25, 0, 0, time.UTC),
+		},
+		"past block": {
+			block:            9760,
+			referenceBlock:   10000,
+			averageBlockTime: 15 * time.Second,
+
+			// -240 blocks * 15s = -1h
+			expectedBlockTime: time.Date(2020, 5, 10, 11, 0, 0, 0, time.UTC),
+		},
+		"reference block": {
+			block:            10000,
+			referenceBlock:   10000,
+			averageBlockTime: 15 * time.Second,
+
+			expectedBlockTime: referenceBlockTime,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			blockTime := estimateBlockTime(
+				test.block,
+				test.referenceBlock,
+				referenceBlockTime,
+				test.averageBlockTime,
+			)
+
+			if !blockTime.Equal(test.expectedBlockTime) {
+				t.Errorf(
+					"unexpected block time\nexpected: [%v]\nactual:   [%v]",
+					test.expectedBlockTime,
+					blockTime,
+				)
+			}
+		})
+	}
+}
+
+func TestSummarizeRelayEntries(t *testing.T) {
+	operator := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	otherOperator := "0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+
+	oldContract := "0x1111111111111111111111111111111111111111"
+	newContract := "0x2222222222222222222222222222222222222222"
+
+	brg := &BeaconReportGenerator{
+		operatorContracts: []string{oldContract, newContract},
+		groups: []*group{
+			{
+				operatorContract: oldContract,
+				index:            0,
+				publicKey:        []byte{0x01},
+				members:          map[int]string{1: operator, 2: otherOperator},
+			},
+			{
+				operatorContract: oldContract,
+				index:            1,
+				publicKey:        []byte{0x02},
+				members:          map[int]string{1: otherOperator, 2: otherOperator},
+			},
+			{
+				operatorContract: newContract,
+				index:            0,
+				publicKey:        []byte{0x03},
+				members:          map[int]string{1: otherOperator, 2: otherOperator},
+			},
+		},
+		relayEntryEvents: []*chain.RelayEntryEvent{
+			// produced by the operator's group
+			{
+				Type:             chain.RelayEntryRequested,
+				OperatorContract: oldContract,
+				GroupPublicKey:   []byte{0x01},
+			},
+			{Type: chain.RelayEntrySubmitted, OperatorContract: oldContract},
+			// produced by other group
+			{
+				Type:             chain.RelayEntryRequested,
+				OperatorContract: oldContract,
+				GroupPublicKey:   []byte{0x02},
+			},
+			{Type: chain.RelayEntrySubmitted, OperatorContract: oldContract},
+			// timed out by the operator's group
+			{
+				Type:             chain.RelayEntryRequested,
+				OperatorContract: oldContract,
+				GroupPublicKey:   []byte{0x01},
+			},
+			// timed out by other group with the same index in the new contract
+			{
+				Type:             chain.RelayEntryRequested,
+				OperatorContract: newContract,
+				GroupPublicKey:   []byte{0x03},
+			},
+			{
+				Type:             chain.RelayEntryTimedOut,
+				OperatorContract: newContract,
+				GroupIndex:       0,
+			},
+			{
+				Type:             chain.RelayEntryTimedOut,
+				OperatorContract: oldContract,
+				GroupIndex:       0,
+			},
+			// submission without a request from the reporting period
+			{Type: chain.RelayEntrySubmitted, OperatorContract: newContract},
+		},
+	}
+
+	requested, produced, timedOut := brg.summarizeRelayEntries(operator)
+
+	if requested != 2 {
+		t.Errorf("unexpected requested count\nexpected: [2]\nactual:   [%v]", requested)
+	}
+	if produced != 1 {
+		t.Errorf("unexpected produced count\nexpected: [1]\nactual:   [%v]", produced)
+	}
+	if timedOut != 1 {
+		t.Errorf("unexpected timed out count\nexpected: [1]\nactual:   [%v]", timedOut)
+	}
+}
+
+// groupRewardsDataSource returns fixed member rewards keyed by the group
+// public key.
+type groupRewardsDataSource struct {
+	BeaconDataSource
+
+	rewards map[string]*big.Int
+}
+
+func (grds *groupRewardsDataSource) GroupMemberRewards(
+	ctx context.Context,
+	operatorContract string,
+	groupPublicKey []byte,
+) (*big.Int, error) {
+	return grds.rewards[string(groupPublicKey)], nil
+}
+
+func TestCalculateUnlockingRewards(t *testing.T) {
+	operator := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	otherOperator := "0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+
+	operatorContract := "0x1111111111111111111111111111111111111111"
+
+	eth := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	brg := &BeaconReportGenerator{
+		dataSource: &groupRewardsDataSource{
+			rewards: map[string]*big.Int{
+				"\x01": eth,
+				"\x02": new(big.Int).Div(eth, big.NewInt(2)),
+				"\x03": eth,
+				"\x04": eth,
+				"\x05": eth,
+			},
+		},
+		period:           &Period{StartBlock: 0, EndBlock: 1000},
+		averageBlockTime: 15 * time.Second,
+		groups: []*group{
+			// two operator members, stale 100 blocks after the period end
+			{
+				operatorContract: operatorContract,
+				isActive:         true,
+				publicKey:        []byte{0x01},
+				members:          map[int]string{1: operator, 2: operator},
+				staleBlock:       1100,
+			},
+			// one operator member, stale 240 blocks after the period end
+			{
+				operatorContract: operatorContract,
+				isActive:         true,
+				publicKey:        []byte{0x02},
+				members:          map[int]string{1: operator, 2: otherOperator},
+				staleBlock:       1240,
+			},
+			// stale 300 blocks after the period end
+			{
+				operatorContract: operatorContract,
+				isActive:         true,
+				publicKey:        []byte{0x03},
+				members:          map[int]string{1: operator},
+				staleBlock:       1300,
+			},
+			// no longer active
+			{
+				operatorContract: operatorContract,
+				isActive:         false,
+				publicKey:        []byte{0x04},
+				members:          map[int]string{1: operator},
+				staleBlock:       900,
+			},
+			// no operator members
+			{
+				operatorContract: operatorContract,
+				isActive:         true,
+				publicKey:        []byte{0x05},
+				members:          map[int]string{1: otherOperator},
+				staleBlock:       1100,
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		period time.Duration
+
+		expectedRewards string
+	}{
+		"no groups stale within the period": {
+			period: 0,
+
+			expectedRewards: "0.000000",
+		},
+		"some groups stale within the period": {
+			// 120 blocks * 15s = 30min
+			period: 30 * time.Minute,
+
+			// 2 members * 1 ETH
+			expectedRewards: "2.000000",
+		},
+		"group stale at the period end": {
+			// 240 blocks * 15s = 1h
+			period: time.Hour,
+
+			// 2 members * 1 ETH + 1 member * 0.5 ETH
+			expectedRewards: "2.500000",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			rewards, err := brg.calculateUnlockingRewards(
+				context.Background(),
+				operator,
+				test.period,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rewards.Text('f', 6) != test.expectedRewards {
+				t.Errorf(
+					"unexpected unlocking rewards\n"+
+						"expected: [%v]\nactual:   [%v]",
+					test.expectedRewards,
+					rewards.Text('f', 6),
+				)
+			}
+		})
+	}
+}
@@ -1,37 +1,582 @@
 package billing
 
 import (
+	"context"
+	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/boar-network/keep-billings/pkg/chain"
 	"github.com/ipfs/go-log"
 )
 
 var logger = log.Logger("billings-billing")
 
 type Customer struct {
-	Name                    string
-	Operator                string
+	Name     string
+	Operator string
+	// optional, resolved from the staking contract if not set
+	Owner string
+	// optional, resolved from the staking contract if not set
 	Beneficiary             string
 	CustomerSharePercentage int
+	// optional, generated billings are sent there by the send command
+	Email string
+	// optional, overrides the billing template configured for all customers
+	TemplateFile string
+	// optional, name of the branding presenting the billing; the default
+	// branding is used if not set
+	Branding string
+	// optional, overrides the language configured for all customers
+	Language string
+	// optional, the customer's shares are paid out there by the payouts
+	// command; the owner if not set
+	PayoutAddress string
+}
+
+// RewardToken is an additional ERC20 token rewarded for staking. Only the
+// tokens transferred to the beneficiary by one of the distributors are
+// considered rewards.
+type RewardToken struct {
+	Address      string
+	Distributors []string
+}
+
+// Period determines the range of blocks covered by the report. Zero end
+// block means the period ends at the current block.
+type Period struct {
+	StartBlock uint64
+	EndBlock   uint64
 }
 
 type Report struct {
 	Customer *Customer
 
-	Stake                  string
-	OperatorBalance        string
+	PeriodStartBlock uint64
+	PeriodEndBlock   uint64
+	PeriodEndDate    string
+
+	Stake           string
+	OperatorBalance string
+	// ETH and KEEP received by the beneficiary during the period which
+	// are attributable to staking
 	BeneficiaryEthBalance  string
 	BeneficiaryKeepBalance string
+	// all ETH and KEEP held by the beneficiary, informational only
+	BeneficiaryRawEthBalance  string
+	BeneficiaryRawKeepBalance string
 
 	AccumulatedRewards string
 	CustomerEthShare   string
 	ProviderEthShare   string
 	CustomerKeepShare  string
 	ProviderKeepShare  string
+	// shares of ETH withdrawn to the beneficiary during the period only;
+	// accumulated rewards are still held by operator contracts and are
+	// reported again each period until withdrawn, so only these shares
+	// can be paid out or booked
+	CustomerReceivedEthShare string
+	ProviderReceivedEthShare string
+
+	StakeAtPeriodStart string
+	StakeAtPeriodEnd   string
+	StakeDelta         string
+	StakePenalties     []*StakePenaltySummary
+	TotalPenalties     string
+
+	Delegation *DelegationSummary
+
+	KeepLedgerOpeningBalance string
+	KeepLedgerClosingBalance string
+	KeepLedger               []*LedgerEntry
+
+	TokenRewards []*TokenRewardSummary
+
+	// set only if reports are attested
+	Attestation *Attestation
+}
+
+// Attestation is the Ethereum signature of the report attestation message,
+// made following EIP-191 (personal_sign).
+type Attestation struct {
+	Message   string
+	Signer    string
+	Signature string
+}
+
+type TokenRewardSummary struct {
+	Symbol        string
+	Received      string
+	CustomerShare string
+	ProviderShare string
+}
+
+// LedgerEntry is a single token transfer to or from the beneficiary.
+// Negative amount means the transfer was made by the beneficiary.
+type LedgerEntry struct {
+	BlockNumber  uint64
+	TxHash       string
+	Counterparty string
+	Amount       string
+	Balance      string
+}
+
+type DelegationSummary struct {
+	Owner                      string
+	Beneficiary                string
+	Authorizer                 string
+	Amount                     string
+	CreatedAt                  string
+	Status                     string
+	OperatorContractAuthorized bool
+	Locks                      []string
+}
+
+type StakePenaltySummary struct {
+	Type        string
+	BlockNumber uint64
+	TxHash      string
+	Amount      string
 }
 
 type DataSource interface {
-	EthBalance(address string) (*big.Float, error)
-	Stake(address string) (*big.Float, error)
-	KeepBalance(address string) (*big.Float, error)
+	// CurrentBlock returns the most recent block considered final, that is,
+	// having the configured number of confirmations.
+	CurrentBlock(ctx context.Context) (uint64, error)
+	// PinBlock makes all state reads without an explicit block, like
+	// balances or group data, return the state as of the given block.
+	PinBlock(blockNumber uint64)
+	BlockTime(ctx context.Context, blockNumber uint64) (time.Time, error)
+
+	EthBalance(ctx context.Context, address string) (*big.Float, error)
+	Stake(ctx context.Context, address string) (*big.Float, error)
+	StakeAt(
+		ctx context.Context,
+		address string,
+		blockNumber uint64,
+	) (*big.Float, error)
+	KeepBalance(ctx context.Context, address string) (*big.Float, error)
+	KeepBalanceAt(
+		ctx context.Context,
+		address string,
+		blockNumber uint64,
+	) (*big.Float, error)
+
+	Delegation(ctx context.Context, operator string) (*chain.Delegation, error)
+	StakePenalties(
+		ctx context.Context,
+		operator string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.StakePenalty, error)
+	IncomingKeepTransfers(
+		ctx context.Context,
+		recipient string,
+		senders []string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.TokenTransfer, error)
+	KeepTransfers(
+		ctx context.Context,
+		address string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.TokenTransfer, error)
+
+	TokenSymbol(ctx context.Context, tokenAddress string) (string, error)
+	IncomingTokenTransfers(
+		ctx context.Context,
+		tokenAddress string,
+		recipient string,
+		senders []string,
+		startBlock uint64,
+		endBlock uint64,
+	) ([]*chain.TokenTransfer, error)
+}
+
+// summarizeStakeChanges compares the operator's stake at the beginning and
+// the end of the period and lists all penalties which took place in
+// between. Fills the corresponding report fields.
+func summarizeStakeChanges(
+	ctx context.Context,
+	dataSource DataSource,
+	operator string,
+	period *Period,
+	report *Report,
+) error {
+	stakeAtStart, err := dataSource.StakeAt(ctx, operator, period.StartBlock)
+	if err != nil {
+		return fmt.Errorf(
+			"could not get stake at block [%v]: [%v]",
+			period.StartBlock,
+			err,
+		)
+	}
+
+	stakeAtEnd, err := dataSource.StakeAt(ctx, operator, period.EndBlock)
+	if err != nil {
+		return fmt.Errorf(
+			"could not get stake at block [%v]: [%v]",
+			period.EndBlock,
+			err,
+		)
+	}
+
+	penalties, err := dataSource.StakePenalties(
+		ctx,
+		operator,
+		period.StartBlock,
+		period.EndBlock,
+	)
+	if err != nil {
+		return fmt.Errorf("could not get stake penalties: [%v]", err)
+	}
+
+	totalPenalties := big.NewFloat(0)
+	penaltySummaries := make([]*StakePenaltySummary, 0)
+
+	for _, penalty := range penalties {
+		penaltyType := "Slashed"
+		if penalty.Type == chain.StakeSeized {
+			penaltyType = "Seized"
+		}
+
+		penaltySummaries = append(penaltySummaries, &StakePenaltySummary{
+			Type:        penaltyType,
+			BlockNumber: penalty.BlockNumber,
+			TxHash:      penalty.TxHash,
+			Amount:      penalty.Amount.Text('f', 6),
+		})
+
+		totalPenalties = new(big.Float).Add(totalPenalties, penalty.Amount)
+	}
+
+	report.StakeAtPeriodStart = stakeAtStart.Text('f', 6)
+	report.StakeAtPeriodEnd = stakeAtEnd.Text('f', 6)
+	report.StakeDelta = new(big.Float).Sub(stakeAtEnd, stakeAtStart).Text('f', 6)
+	report.StakePenalties = penaltySummaries
+	report.TotalPenalties = totalPenalties.Text('f', 6)
+
+	return nil
+}
+
+// zeroAddress is returned by the staking contract for operators without
+// a delegation.
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// resolveCustomerAddresses fills the customer's owner and beneficiary
+// addresses not provided in the customers file with the ones set for the
+// operator in the staking contract. Addresses provided in the customers file
+// must match the ones from the staking contract, which must be set.
+func resolveCustomerAddresses(
+	customer *Customer,
+	delegation *chain.Delegation,
+) error {
+	if strings.EqualFold(delegation.Owner, zeroAddress) ||
+		strings.EqualFold(delegation.Beneficiary, zeroAddress) {
+		return fmt.Errorf(
+			"operator [%v] of customer [%v] has no owner or beneficiary "+
+				"in the staking contract",
+			customer.Operator,
+			customer.Name,
+		)
+	}
+
+	if len(customer.Owner) == 0 {
+		customer.Owner = delegation.Owner
+	} else if !strings.EqualFold(customer.Owner, delegation.Owner) {
+		return fmt.Errorf(
+			"owner [%v] of customer [%v] differs from the on-chain owner [%v]",
+			customer.Owner,
+			customer.Name,
+			delegation.Owner,
+		)
+	}
+
+	if len(customer.Beneficiary) == 0 {
+		customer.Beneficiary = delegation.Beneficiary
+	} else if !strings.EqualFold(customer.Beneficiary, delegation.Beneficiary) {
+		return fmt.Errorf(
+			"beneficiary [%v] of customer [%v] differs from the on-chain "+
+				"beneficiary [%v]",
+			customer.Beneficiary,
+			customer.Name,
+			delegation.Beneficiary,
+		)
+	}
+
+	return nil
+}
+
+// summarizeDelegation fills the report field describing the delegation of
+// the customer's operator.
+func summarizeDelegation(
+	delegation *chain.Delegation,
+	report *Report,
+) {
+	status := "Active"
+	if !delegation.UndelegatedAt.IsZero() {
+		status = "Undelegated at " + formatTime(delegation.UndelegatedAt)
+	}
+
+	locks := make([]string, len(delegation.Locks))
+	for i, lock := range delegation.Locks {
+		locks[i] = fmt.Sprintf(
+			"Locked by %v until %v",
+			lock.Creator,
+			formatTime(lock.Expiration),
+		)
+	}
+
+	report.Delegation = &DelegationSummary{
+		Owner:                      delegation.Owner,
+		Beneficiary:                delegation.Beneficiary,
+		Authorizer:                 delegation.Authorizer,
+		Amount:                     delegation.Amount.Text('f', 0),
+		CreatedAt:                  formatTime(delegation.CreatedAt),
+		Status:                     status,
+		OperatorContractAuthorized: delegation.IsOperatorContractAuthorized,
+		Locks:                      locks,
+	}
+}
+
+// TimeLayout is the layout of all dates and times presented in reports.
+const TimeLayout = "2006-01-02 15:04 MST"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// calculateReceivedKeepRewards sums up KEEP transferred to the beneficiary
+// by the reward distributors during the period. Other KEEP held by the
+// beneficiary is not attributed to staking.
+func calculateReceivedKeepRewards(
+	ctx context.Context,
+	dataSource DataSource,
+	beneficiary string,
+	rewardDistributors []string,
+	period *Period,
+) (*big.Float, error) {
+	transfers, err := dataSource.IncomingKeepTransfers(
+		ctx,
+		beneficiary,
+		rewardDistributors,
+		period.StartBlock,
+		period.EndBlock,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get KEEP reward transfers: [%v]", err)
+	}
+
+	receivedRewards := big.NewFloat(0)
+	for _, transfer := range transfers {
+		receivedRewards = new(big.Float).Add(receivedRewards, transfer.Amount)
+	}
+
+	return receivedRewards, nil
+}
+
+// buildKeepLedger lists all KEEP transfers to and from the given address
+// made during the period along with the balance after each transfer.
+// The opening balance is derived from the balance at the end of the period
+// so the ledger always adds up to the closing balance.
+func buildKeepLedger(
+	ctx context.Context,
+	dataSource DataSource,
+	address string,
+	period *Period,
+	report *Report,
+) error {
+	closingBalance, err := dataSource.KeepBalanceAt(ctx, address, period.EndBlock)
+	if err != nil {
+		return fmt.Errorf(
+			"could not get KEEP balance at block [%v]: [%v]",
+			period.EndBlock,
+			err,
+		)
+	}
+
+	transfers, err := dataSource.KeepTransfers(
+		ctx,
+		address,
+		period.StartBlock,
+		period.EndBlock,
+	)
+	if err != nil {
+		return fmt.Errorf("could not get KEEP transfers: [%v]", err)
+	}
+
+	amounts := make([]*big.Float, len(transfers))
+	openingBalance := new(big.Float).Set(closingBalance)
+
+	for i, transfer := range transfers {
+		amounts[i] = transferAmount(address, transfer)
+		openingBalance = new(big.Float).Sub(openingBalance, amounts[i])
+	}
+
+	ledger := make([]*LedgerEntry, len(transfers))
+	balance := openingBalance
+
+	for i, transfer := range transfers {
+		balance = new(big.Float).Add(balance, amounts[i])
+
+		counterparty := transfer.From
+		if amounts[i].Sign() < 0 {
+			counterparty = transfer.To
+		}
+
+		ledger[i] = &LedgerEntry{
+			BlockNumber:  transfer.BlockNumber,
+			TxHash:       transfer.TxHash,
+			Counterparty: counterparty,
+			Amount:       amounts[i].Text('f', 6),
+			Balance:      balance.Text('f', 6),
+		}
+	}
+
+	report.KeepLedgerOpeningBalance = openingBalance.Text('f', 6)
+	report.KeepLedgerClosingBalance = closingBalance.Text('f', 6)
+	report.KeepLedger = ledger
+
+	return nil
+}
+
+// transferAmount returns the amount of the transfer from the perspective
+// of the given address; negative if the address is the sender. Transfers
+// to self do not change the balance.
+func transferAmount(address string, transfer *chain.TokenTransfer) *big.Float {
+	isSender := strings.EqualFold(transfer.From, address)
+	isRecipient := strings.EqualFold(transfer.To, address)
+
+	switch {
+	case isSender && isRecipient:
+		return big.NewFloat(0)
+	case isSender:
+		return new(big.Float).Neg(transfer.Amount)
+	default:
+		return new(big.Float).Set(transfer.Amount)
+	}
+}
+
+// KeepLedgerRecords returns the KEEP ledger as records ready to be written
+// to a CSV file, including the header record.
+func (r *Report) KeepLedgerRecords() [][]string {
+	records := [][]string{
+		{"Block", "Transaction", "Counterparty", "Amount", "Balance"},
+	}
+
+	for _, entry := range r.KeepLedger {
+		records = append(records, []string{
+			strconv.FormatUint(entry.BlockNumber, 10),
+			entry.TxHash,
+			entry.Counterparty,
+			entry.Amount,
+			entry.Balance,
+		})
+	}
+
+	return records
+}
+
+// AttestationMessage returns the message attesting the key figures of the
+// report: the customer, the period and the shares of all rewards. The
+// message is readable text so it can be reviewed when verified in wallets.
+func (r *Report) AttestationMessage() string {
+	lines := []string{
+		"Keep Random Beacon billing attestation",
+		"Customer: " + r.Customer.Name,
+		"Operator: " + r.Customer.Operator,
+		"Period start block: " + strconv.FormatUint(r.PeriodStartBlock, 10),
+		"Period end block: " + strconv.FormatUint(r.PeriodEndBlock, 10),
+		"Customer ETH share: " + r.CustomerEthShare,
+		"Provider ETH share: " + r.ProviderEthShare,
+		"Customer KEEP share: " + r.CustomerKeepShare,
+		"Provider KEEP share: " + r.ProviderKeepShare,
+	}
+
+	for _, tokenReward := range r.TokenRewards {
+		lines = append(
+			lines,
+			"Customer "+tokenReward.Symbol+" share: "+tokenReward.CustomerShare,
+			"Provider "+tokenReward.Symbol+" share: "+tokenReward.ProviderShare,
+		)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// calculateTokenRewards sums up each of the reward tokens transferred to the
+// beneficiary by the token distributors during the period and splits them
+// between the customer and the provider.
+func calculateTokenRewards(
+	ctx context.Context,
+	dataSource DataSource,
+	customer *Customer,
+	rewardTokens []*RewardToken,
+	period *Period,
+) ([]*TokenRewardSummary, error) {
+	summaries := make([]*TokenRewardSummary, 0)
+
+	for _, rewardToken := range rewardTokens {
+		symbol, err := dataSource.TokenSymbol(ctx, rewardToken.Address)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not get symbol of token [%v]: [%v]",
+				rewardToken.Address,
+				err,
+			)
+		}
+
+		transfers, err := dataSource.IncomingTokenTransfers(
+			ctx,
+			rewardToken.Address,
+			customer.Beneficiary,
+			rewardToken.Distributors,
+			period.StartBlock,
+			period.EndBlock,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not get %v reward transfers: [%v]",
+				symbol,
+				err,
+			)
+		}
+
+		received := big.NewFloat(0)
+		for _, transfer := range transfers {
+			received = new(big.Float).Add(received, transfer.Amount)
+		}
+
+		customerShare, providerShare := splitRewards(
+			big.NewFloat(float64(customer.CustomerSharePercentage)),
+			received,
+		)
+
+		summaries = append(summaries, &TokenRewardSummary{
+			Symbol:        symbol,
+			Received:      received.Text('f', 6),
+			CustomerShare: customerShare.Text('f', 6),
+			ProviderShare: providerShare.Text('f', 6),
+		})
+	}
+
+	return summaries, nil
+}
+
+// splitRewards splits the rewards between the customer and the provider
+// according to the customer share percentage.
+func splitRewards(
+	customerSharePercentage *big.Float,
+	rewards *big.Float,
+) (customerShare *big.Float, providerShare *big.Float) {
+	customerShare = new(big.Float).Quo(
+		new(big.Float).Mul(rewards, customerSharePercentage),
+		big.NewFloat(100),
+	)
+	providerShare = new(big.Float).Sub(rewards, customerShare)
+
+	return
 }
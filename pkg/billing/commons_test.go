@@ -0,0 +1,314 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/boar-network/keep-billings/pkg/chain"
+)
+
+func TestResolveCustomerAddresses(t *testing.T) {
+	delegation := &chain.Delegation{
+		Owner:       "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Beneficiary: "0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB",
+	}
+
+	tests := map[string]struct {
+		owner       string
+		beneficiary string
+		// the default delegation is used if not set
+		delegation *chain.Delegation
+
+		expectedOwner       string
+		expectedBeneficiary string
+		expectedError       bool
+	}{
+		"addresses not provided": {
+			expectedOwner:       delegation.Owner,
+			expectedBeneficiary: delegation.Beneficiary,
+		},
+		"matching addresses provided": {
+			owner:       "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			beneficiary: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+
+			expectedOwner:       "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			expectedBeneficiary: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		},
+		"mismatched beneficiary provided": {
+			beneficiary: "0xCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCC",
+
+			expectedError: true,
+		},
+		"mismatched owner provided": {
+			owner: "0xCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCCC",
+
+			expectedError: true,
+		},
+		"zero on-chain owner": {
+			delegation: &chain.Delegation{
+				Owner:       zeroAddress,
+				Beneficiary: delegation.Beneficiary,
+			},
+
+			expectedError: true,
+		},
+		"zero on-chain beneficiary": {
+			beneficiary: zeroAddress,
+			delegation: &chain.Delegation{
+				Owner:       delegation.Owner,
+				Beneficiary: zeroAddress,
+			},
+
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			customer := &Customer{
+				Name:        "Customer",
+				Owner:       test.owner,
+				Beneficiary: test.beneficiary,
+			}
+
+			testDelegation := delegation
+			if test.delegation != nil {
+				testDelegation = test.delegation
+			}
+
+			err := resolveCustomerAddresses(customer, testDelegation)
+
+			if test.expectedError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if customer.Owner != test.expectedOwner {
+				t.Errorf(
+					"unexpected owner\nexpected: [%v]\nactual:   [%v]",
+					test.expectedOwner,
+					customer.Owner,
+				)
+			}
+			if customer.Beneficiary != test.expectedBeneficiary {
+				t.Errorf(
+					"unexpected beneficiary\nexpected: [%v]\nactual:   [%v]",
+					test.expectedBeneficiary,
+					customer.Beneficiary,
+				)
+			}
+		})
+	}
+}
+
+type ledgerDataSource struct {
+	DataSource
+
+	closingBalance *big.Float
+	transfers      []*chain.TokenTransfer
+}
+
+func (lds *ledgerDataSource) KeepBalanceAt(
+	ctx context.Context,
+	address string,
+	blockNumber uint64,
+) (*big.Float, error) {
+	return lds.closingBalance, nil
+}
+
+func (lds *ledgerDataSource) KeepTransfers(
+	ctx context.Context,
+	address string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*chain.TokenTransfer, error) {
+	return lds.transfers, nil
+}
+
+func TestBuildKeepLedger(t *testing.T) {
+	beneficiary := "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+
+	dataSource := &ledgerDataSource{
+		closingBalance: big.NewFloat(150),
+		transfers: []*chain.TokenTransfer{
+			{BlockNumber: 10, From: other, To: beneficiary, Amount: big.NewFloat(100)},
+			{BlockNumber: 20, From: beneficiary, To: other, Amount: big.NewFloat(30)},
+			{BlockNumber: 30, From: beneficiary, To: beneficiary, Amount: big.NewFloat(5)},
+			{BlockNumber: 40, From: other, To: beneficiary, Amount: big.NewFloat(20)},
+		},
+	}
+
+	report := &Report{}
+
+	err := buildKeepLedger(
+		context.Background(),
+		dataSource,
+		beneficiary,
+		&Period{StartBlock: 1, EndBlock: 50},
+		report,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 150 - 100 + 30 - 0 - 20 = 60
+	if report.KeepLedgerOpeningBalance != "60.000000" {
+		t.Errorf(
+			"unexpected opening balance\nexpected: [60.000000]\nactual:   [%v]",
+			report.KeepLedgerOpeningBalance,
+		)
+	}
+
+	expectedLedger := []*LedgerEntry{
+		{BlockNumber: 10, Counterparty: other, Amount: "100.000000", Balance: "160.000000"},
+		{BlockNumber: 20, Counterparty: other, Amount: "-30.000000", Balance: "130.000000"},
+		{BlockNumber: 30, Counterparty: beneficiary, Amount: "0.000000", Balance: "130.000000"},
+		{BlockNumber: 40, Counterparty: other, Amount: "20.000000", Balance: "150.000000"},
+	}
+
+	if !reflect.DeepEqual(expectedLedger, report.KeepLedger) {
+		for i, entry := range report.KeepLedger {
+			t.Logf("ledger entry [%v]: [%+v]", i, entry)
+		}
+		t.Errorf("unexpected ledger")
+	}
+}
+
+// stakeDataSource serves stakes by block and penalties of a single operator.
+type stakeDataSource struct {
+	DataSource
+
+	stakes    map[uint64]*big.Float
+	penalties []*chain.StakePenalty
+}
+
+func (sds *stakeDataSource) StakeAt(
+	ctx context.Context,
+	address string,
+	blockNumber uint64,
+) (*big.Float, error) {
+	stake, ok := sds.stakes[blockNumber]
+	if !ok {
+		return nil, fmt.Errorf("no stake at block [%v]", blockNumber)
+	}
+
+	return stake, nil
+}
+
+func (sds *stakeDataSource) StakePenalties(
+	ctx context.Context,
+	operator string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*chain.StakePenalty, error) {
+	return sds.penalties, nil
+}
+
+func TestSummarizeStakeChanges(t *testing.T) {
+	var tests = map[string]struct {
+		dataSource             *stakeDataSource
+		expectedStart          string
+		expectedEnd            string
+		expectedDelta          string
+		expectedPenalties      []*StakePenaltySummary
+		expectedTotalPenalties string
+	}{
+		"stake increased": {
+			dataSource: &stakeDataSource{
+				stakes: map[uint64]*big.Float{
+					100: big.NewFloat(0),
+					200: big.NewFloat(100000.5),
+				},
+			},
+			expectedStart:          "0.000000",
+			expectedEnd:            "100000.500000",
+			expectedDelta:          "100000.500000",
+			expectedPenalties:      []*StakePenaltySummary{},
+			expectedTotalPenalties: "0.000000",
+		},
+		"stake penalized": {
+			dataSource: &stakeDataSource{
+				stakes: map[uint64]*big.Float{
+					100: big.NewFloat(100000),
+					200: big.NewFloat(99850),
+				},
+				penalties: []*chain.StakePenalty{
+					{
+						Type:        chain.StakeSlashed,
+						BlockNumber: 120,
+						TxHash:      "0x01",
+						Amount:      big.NewFloat(100),
+					},
+					{
+						Type:        chain.StakeSeized,
+						BlockNumber: 150,
+						TxHash:      "0x02",
+						Amount:      big.NewFloat(50),
+					},
+				},
+			},
+			expectedStart: "100000.000000",
+			expectedEnd:   "99850.000000",
+			expectedDelta: "-150.000000",
+			expectedPenalties: []*StakePenaltySummary{
+				{Type: "Slashed", BlockNumber: 120, TxHash: "0x01", Amount: "100.000000"},
+				{Type: "Seized", BlockNumber: 150, TxHash: "0x02", Amount: "50.000000"},
+			},
+			expectedTotalPenalties: "150.000000",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			report := &Report{}
+
+			err := summarizeStakeChanges(
+				context.Background(),
+				test.dataSource,
+				"0xOperator",
+				&Period{StartBlock: 100, EndBlock: 200},
+				report,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := []string{
+				report.StakeAtPeriodStart,
+				report.StakeAtPeriodEnd,
+				report.StakeDelta,
+				report.TotalPenalties,
+			}
+			expected := []string{
+				test.expectedStart,
+				test.expectedEnd,
+				test.expectedDelta,
+				test.expectedTotalPenalties,
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf(
+					"unexpected stake changes\nexpected: [%v]\nactual:   [%v]",
+					expected,
+					actual,
+				)
+			}
+
+			if !reflect.DeepEqual(test.expectedPenalties, report.StakePenalties) {
+				for i, penalty := range report.StakePenalties {
+					t.Logf("penalty [%v]: [%+v]", i, penalty)
+				}
+				t.Errorf("unexpected penalties")
+			}
+		})
+	}
+}
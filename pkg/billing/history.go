@@ -0,0 +1,100 @@
+package billing
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// HistoryPoint summarizes the customer's billing of a single period.
+type HistoryPoint struct {
+	PeriodEndBlock           uint64
+	PeriodEndDate            string
+	CustomerEthShare         float64
+	CustomerKeepShare        float64
+	Stake                    float64
+	ActiveGroupsMembersCount int
+}
+
+// History is the customer's billing history over subsequent periods,
+// ordered from the oldest one.
+type History []*HistoryPoint
+
+// NewHistoryPoint summarizes the report as a point of the billing history.
+func NewHistoryPoint(report *BeaconReport) (*HistoryPoint, error) {
+	point := &HistoryPoint{
+		PeriodEndBlock:           report.PeriodEndBlock,
+		PeriodEndDate:            report.PeriodEndDate,
+		ActiveGroupsMembersCount: report.ActiveGroupsMembersCount,
+	}
+
+	figures := map[string]struct {
+		value  string
+		target *float64
+	}{
+		"customer ETH share":  {report.CustomerEthShare, &point.CustomerEthShare},
+		"customer KEEP share": {report.CustomerKeepShare, &point.CustomerKeepShare},
+		"stake":               {report.Stake, &point.Stake},
+	}
+
+	for name, figure := range figures {
+		value, err := strconv.ParseFloat(figure.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %v: [%v]", name, err)
+		}
+
+		*figure.target = value
+	}
+
+	return point, nil
+}
+
+// Labels returns the period end dates. Periods without the end date are
+// labelled with the end block.
+func (h History) Labels() []string {
+	labels := make([]string, len(h))
+	for i, point := range h {
+		date, err := time.Parse(TimeLayout, point.PeriodEndDate)
+		if err != nil {
+			labels[i] = "#" + strconv.FormatUint(point.PeriodEndBlock, 10)
+			continue
+		}
+
+		labels[i] = date.Format("2006-01-02")
+	}
+
+	return labels
+}
+
+func (h History) CustomerEthShares() []float64 {
+	return h.values(func(point *HistoryPoint) float64 {
+		return point.CustomerEthShare
+	})
+}
+
+func (h History) CustomerKeepShares() []float64 {
+	return h.values(func(point *HistoryPoint) float64 {
+		return point.CustomerKeepShare
+	})
+}
+
+func (h History) Stakes() []float64 {
+	return h.values(func(point *HistoryPoint) float64 {
+		return point.Stake
+	})
+}
+
+func (h History) ActiveGroupsMembersCounts() []float64 {
+	return h.values(func(point *HistoryPoint) float64 {
+		return float64(point.ActiveGroupsMembersCount)
+	})
+}
+
+func (h History) values(value func(point *HistoryPoint) float64) []float64 {
+	values := make([]float64, len(h))
+	for i, point := range h {
+		values[i] = value(point)
+	}
+
+	return values
+}
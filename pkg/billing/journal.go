@@ -0,0 +1,347 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// journalDateLayout is the layout of journal entry dates.
+const journalDateLayout = "2006-01-02"
+
+// JournalAccounts is the chart of accounts journal entries are posted to.
+type JournalAccounts struct {
+	// rewards received by the beneficiary, before they are split
+	RewardsClearing string
+	// the provider's share of rewards
+	ProviderRevenue string
+	// the customer's share of rewards, owed to the customer
+	CustomerPayable string
+	// transaction fees paid by the operator
+	GasExpense string
+	// ETH held by the operator to pay transaction fees
+	OperatorWallet string
+}
+
+// JournalEntry is a balanced double-entry journal entry. All entries of a
+// billing are dated at the end of the billing period.
+type JournalEntry struct {
+	Date      string
+	Reference string
+	Narration string
+	Lines     []*JournalLine
+}
+
+// JournalLine posts an amount of a token to an account. Positive amounts
+// are debits and negative amounts are credits. The value is the amount in
+// the journal currency.
+type JournalLine struct {
+	Account string
+	Symbol  string
+	Amount  string
+	Value   string
+}
+
+// NewJournal books the billing: rewards received in each token split into
+// the provider's revenue and the amount payable to the customer, and the
+// operator's gas costs. ETH rewards accumulated in operator contracts are
+// booked only once withdrawn to the beneficiary, as they are reported again
+// each period until then. Amounts are valued at prices of the period end
+// day. Tokens with no rewards are skipped.
+func NewJournal(
+	ctx context.Context,
+	report *BeaconReport,
+	accounts *JournalAccounts,
+	prices PriceSource,
+) ([]*JournalEntry, error) {
+	periodEnd, err := time.Parse(TimeLayout, report.PeriodEndDate)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse period end date: [%v]", err)
+	}
+
+	reference := fmt.Sprintf(
+		"%v %v-%v",
+		report.Customer.Name,
+		report.PeriodStartBlock,
+		report.PeriodEndBlock,
+	)
+
+	type rewards struct {
+		symbol        string
+		customerShare string
+		providerShare string
+	}
+
+	allRewards := []*rewards{
+		{"ETH", report.CustomerReceivedEthShare, report.ProviderReceivedEthShare},
+		{"KEEP", report.CustomerKeepShare, report.ProviderKeepShare},
+	}
+	for _, tokenRewards := range report.TokenRewards {
+		allRewards = append(allRewards, &rewards{
+			tokenRewards.Symbol,
+			tokenRewards.CustomerShare,
+			tokenRewards.ProviderShare,
+		})
+	}
+
+	entries := make([]*JournalEntry, 0)
+
+	for _, tokenRewards := range allRewards {
+		entry, err := newJournalEntry(
+			ctx,
+			prices,
+			periodEnd,
+			tokenRewards.symbol,
+			[]string{accounts.ProviderRevenue, accounts.CustomerPayable},
+			[]string{tokenRewards.providerShare, tokenRewards.customerShare},
+			accounts.RewardsClearing,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		entry.Reference = reference
+		entry.Narration = fmt.Sprintf(
+			"%v staking rewards of %v, blocks %v-%v",
+			tokenRewards.symbol,
+			report.Customer.Name,
+			report.PeriodStartBlock,
+			report.PeriodEndBlock,
+		)
+		entries = append(entries, entry)
+	}
+
+	gasEntry, err := newJournalEntry(
+		ctx,
+		prices,
+		periodEnd,
+		"ETH",
+		[]string{accounts.OperatorWallet},
+		[]string{report.TotalGasCosts},
+		accounts.GasExpense,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if gasEntry != nil {
+		gasEntry.Reference = reference
+		gasEntry.Narration = fmt.Sprintf(
+			"Gas costs of operator %v of %v, blocks %v-%v",
+			report.Customer.Operator,
+			report.Customer.Name,
+			report.PeriodStartBlock,
+			report.PeriodEndBlock,
+		)
+		entries = append(entries, gasEntry)
+	}
+
+	return entries, nil
+}
+
+// newJournalEntry credits the given amounts to the credit accounts and
+// debits their sum to the debit account. The debited value is the sum of
+// the rounded credited values so the entry balances in the currency too.
+// Returns nil if all amounts are zero.
+func newJournalEntry(
+	ctx context.Context,
+	prices PriceSource,
+	at time.Time,
+	symbol string,
+	creditAccounts []string,
+	creditAmounts []string,
+	debitAccount string,
+) (*JournalEntry, error) {
+	total := big.NewFloat(0)
+	amounts := make([]*big.Float, len(creditAmounts))
+
+	for i, creditAmount := range creditAmounts {
+		amount, ok := new(big.Float).SetString(creditAmount)
+		if !ok {
+			return nil, fmt.Errorf(
+				"could not parse %v amount [%v]",
+				symbol,
+				creditAmount,
+			)
+		}
+
+		amounts[i] = amount
+		total = new(big.Float).Add(total, amount)
+	}
+
+	if total.Sign() == 0 {
+		return nil, nil
+	}
+
+	price, err := prices.Price(ctx, symbol, at)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not get %v price at [%v]: [%v]",
+			symbol,
+			formatTime(at),
+			err,
+		)
+	}
+
+	lines := make([]*JournalLine, 0, len(amounts)+1)
+	totalValue := big.NewFloat(0)
+
+	for i, amount := range amounts {
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		// rounded the way it's presented
+		value, _ := new(big.Float).SetString(
+			new(big.Float).Mul(amount, price).Text('f', 2),
+		)
+		totalValue = new(big.Float).Add(totalValue, value)
+
+		lines = append(lines, &JournalLine{
+			Account: creditAccounts[i],
+			Symbol:  symbol,
+			Amount:  new(big.Float).Neg(amount).Text('f', 6),
+			Value:   new(big.Float).Neg(value).Text('f', 2),
+		})
+	}
+
+	debitLine := &JournalLine{
+		Account: debitAccount,
+		Symbol:  symbol,
+		Amount:  total.Text('f', 6),
+		Value:   totalValue.Text('f', 2),
+	}
+
+	return &JournalEntry{
+		Date:  at.UTC().Format(journalDateLayout),
+		Lines: append([]*JournalLine{debitLine}, lines...),
+	}, nil
+}
+
+// JournalRecords returns the journal as records of a generic journal CSV
+// file, one record for each line, including the header record.
+func JournalRecords(journal []*JournalEntry, currency string) [][]string {
+	records := [][]string{
+		{
+			"Date",
+			"Reference",
+			"Narration",
+			"Account",
+			"Token",
+			"Debit",
+			"Credit",
+			"Debit (" + currency + ")",
+			"Credit (" + currency + ")",
+		},
+	}
+
+	for _, entry := range journal {
+		for _, line := range entry.Lines {
+			debit, credit := splitDebitCredit(line.Amount)
+			debitValue, creditValue := splitDebitCredit(line.Value)
+
+			records = append(records, []string{
+				entry.Date,
+				entry.Reference,
+				entry.Narration,
+				line.Account,
+				line.Symbol,
+				debit,
+				credit,
+				debitValue,
+				creditValue,
+			})
+		}
+	}
+
+	return records
+}
+
+// XeroJournalRecords returns the journal as records of a Xero manual journal
+// import CSV file, including the header record. Xero books in the currency
+// of the organisation so lines carry values only.
+func XeroJournalRecords(journal []*JournalEntry, taxRate string) [][]string {
+	records := [][]string{
+		{
+			"*Narration",
+			"*Date",
+			"Description",
+			"*AccountCode",
+			"*TaxRate",
+			"*Amount",
+		},
+	}
+
+	for _, entry := range journal {
+		for _, line := range entry.Lines {
+			records = append(records, []string{
+				entry.Narration,
+				formatJournalDate(entry.Date, "02/01/2006"),
+				fmt.Sprintf("%v %v", line.Amount, line.Symbol),
+				line.Account,
+				taxRate,
+				line.Value,
+			})
+		}
+	}
+
+	return records
+}
+
+// IifJournalRecords returns the journal as records of a QuickBooks IIF file
+// of general journal transactions, including the header records. Lines are
+// booked by their values and attributed to the given name.
+func IifJournalRecords(journal []*JournalEntry, name string) [][]string {
+	records := [][]string{
+		{"!TRNS", "TRNSTYPE", "DATE", "ACCNT", "NAME", "AMOUNT", "DOCNUM", "MEMO"},
+		{"!SPL", "TRNSTYPE", "DATE", "ACCNT", "NAME", "AMOUNT", "DOCNUM", "MEMO"},
+		{"!ENDTRNS"},
+	}
+
+	for _, entry := range journal {
+		for i, line := range entry.Lines {
+			recordType := "SPL"
+			if i == 0 {
+				recordType = "TRNS"
+			}
+
+			records = append(records, []string{
+				recordType,
+				"GENERAL JOURNAL",
+				formatJournalDate(entry.Date, "01/02/2006"),
+				line.Account,
+				name,
+				line.Value,
+				entry.Reference,
+				fmt.Sprintf("%v: %v %v", entry.Narration, line.Amount, line.Symbol),
+			})
+		}
+
+		records = append(records, []string{"ENDTRNS"})
+	}
+
+	return records
+}
+
+// splitDebitCredit splits a signed amount into debit and credit columns.
+func splitDebitCredit(amount string) (string, string) {
+	if strings.HasPrefix(amount, "-") {
+		return "", strings.TrimPrefix(amount, "-")
+	}
+
+	return amount, ""
+}
+
+// formatJournalDate formats the journal entry date with the given layout.
+func formatJournalDate(date string, layout string) string {
+	t, err := time.Parse(journalDateLayout, date)
+	if err != nil {
+		return date
+	}
+
+	return t.Format(layout)
+}
@@ -0,0 +1,90 @@
+package billing
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestNewJournal(t *testing.T) {
+	accounts := &JournalAccounts{
+		RewardsClearing: "1210",
+		ProviderRevenue: "4000",
+		CustomerPayable: "2100",
+		GasExpense:      "6100",
+		OperatorWallet:  "1220",
+	}
+
+	prices := fixedPriceSource{
+		"ETH":  big.NewFloat(2000),
+		"KEEP": big.NewFloat(0.333),
+	}
+
+	report := &BeaconReport{
+		Report: &Report{
+			Customer: &Customer{
+				Name:     "Customer",
+				Operator: "0xOperator",
+			},
+			PeriodStartBlock: 100,
+			PeriodEndBlock:   200,
+			PeriodEndDate:    "2026-01-31 23:59 UTC",
+			// shares including accumulated rewards are never booked
+			CustomerEthShare:         "1.100000",
+			ProviderEthShare:         "0.500000",
+			CustomerReceivedEthShare: "0.100000",
+			ProviderReceivedEthShare: "0.000000",
+			CustomerKeepShare:        "10.000000",
+			ProviderKeepShare:        "5.000000",
+		},
+		TotalGasCosts: "0.002000",
+	}
+
+	journal, err := NewJournal(context.Background(), report, accounts, prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedJournal := []*JournalEntry{
+		{
+			Date:      "2026-01-31",
+			Reference: "Customer 100-200",
+			Narration: "ETH staking rewards of Customer, blocks 100-200",
+			Lines: []*JournalLine{
+				{"1210", "ETH", "0.100000", "200.00"},
+				{"2100", "ETH", "-0.100000", "-200.00"},
+			},
+		},
+		{
+			Date:      "2026-01-31",
+			Reference: "Customer 100-200",
+			Narration: "KEEP staking rewards of Customer, blocks 100-200",
+			Lines: []*JournalLine{
+				// balanced with the rounded credits: 1.67 + 3.33
+				{"1210", "KEEP", "15.000000", "5.00"},
+				{"4000", "KEEP", "-5.000000", "-1.67"},
+				{"2100", "KEEP", "-10.000000", "-3.33"},
+			},
+		},
+		{
+			Date:      "2026-01-31",
+			Reference: "Customer 100-200",
+			Narration: "Gas costs of operator 0xOperator of Customer, blocks 100-200",
+			Lines: []*JournalLine{
+				{"6100", "ETH", "0.002000", "4.00"},
+				{"1220", "ETH", "-0.002000", "-4.00"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expectedJournal, journal) {
+		for i, entry := range journal {
+			t.Logf("entry [%v]: [%+v]", i, entry)
+			for _, line := range entry.Lines {
+				t.Logf("  line: [%+v]", line)
+			}
+		}
+		t.Errorf("unexpected journal")
+	}
+}
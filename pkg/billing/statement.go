@@ -0,0 +1,601 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/chain"
+)
+
+// Kinds of reward events listed in annual statements.
+const (
+	// ETH rewards withdrawn from an operator contract to the beneficiary
+	RewardsWithdrawn = "withdrawal"
+	// KEEP or another reward token transferred to the beneficiary by one
+	// of the token distributors
+	RewardsDistributed = "distribution"
+	// ETH rewards of a group which expired, becoming withdrawable
+	RewardsUnlocked = "group_expiry"
+)
+
+// PriceSource provides historical token prices in a fiat currency.
+type PriceSource interface {
+	Price(ctx context.Context, symbol string, at time.Time) (*big.Float, error)
+}
+
+// AnnualStatement lists rewards of the customer received during the
+// calendar year along with their fiat value at the time of receipt.
+//
+// Rewards are received when they reach the beneficiary. Rewards unlocked by
+// group expiries are listed separately, for information, since they become
+// withdrawable at that time but are received only once withdrawn.
+type AnnualStatement struct {
+	Customer *Customer
+	Year     int
+	Currency string
+
+	PeriodStartBlock uint64
+	PeriodEndBlock   uint64
+	PeriodStartDate  string
+	PeriodEndDate    string
+
+	Receipts                []*RewardReceipt
+	Totals                  []*RewardTotal
+	TotalValue              string
+	CustomerShareTotalValue string
+
+	UnlockedRewards           []*RewardReceipt
+	UnlockedRewardsTotal      string
+	UnlockedRewardsTotalValue string
+}
+
+// RewardReceipt is a single reward event valued at its block time.
+type RewardReceipt struct {
+	Kind               string
+	BlockNumber        uint64
+	Date               string
+	TxHash             string
+	Symbol             string
+	Amount             string
+	CustomerShare      string
+	Price              string
+	Value              string
+	CustomerShareValue string
+}
+
+// RewardTotal sums up rewards received in a single token.
+type RewardTotal struct {
+	Symbol             string
+	Amount             string
+	CustomerShare      string
+	Value              string
+	CustomerShareValue string
+}
+
+// rewardEvent is a reward event before it is valued.
+type rewardEvent struct {
+	kind        string
+	blockNumber uint64
+	txHash      string
+	symbol      string
+	amount      *big.Float
+}
+
+type AnnualStatementGenerator struct {
+	dataSource BeaconDataSource
+	beacon     *BeaconReportGenerator
+
+	year        int
+	prices      PriceSource
+	currency    string
+	periodStart time.Time
+	periodEnd   time.Time
+
+	blockTimes map[uint64]time.Time
+}
+
+// NewAnnualStatementGenerator creates a generator of statements for the
+// given calendar year, in UTC, with rewards valued in the given currency.
+// The period passed to the beacon report generator is resolved from the
+// year when common data are fetched.
+func NewAnnualStatementGenerator(
+	dataSource BeaconDataSource,
+	year int,
+	period *Period,
+	keepRewardDistributors []string,
+	rewardTokens []*RewardToken,
+	prices PriceSource,
+	currency string,
+) *AnnualStatementGenerator {
+	return &AnnualStatementGenerator{
+		dataSource: dataSource,
+		beacon: NewBeaconReportGenerator(
+			dataSource,
+			period,
+			keepRewardDistributors,
+			rewardTokens,
+		),
+		year:       year,
+		prices:     prices,
+		currency:   strings.ToUpper(currency),
+		blockTimes: make(map[uint64]time.Time),
+	}
+}
+
+func (asg *AnnualStatementGenerator) FetchCommonData(ctx context.Context) error {
+	yearStart := time.Date(asg.year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := yearStart.AddDate(1, 0, 0)
+
+	currentBlock, err := asg.dataSource.CurrentBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get current block: [%v]", err)
+	}
+
+	startBlock, found, err := asg.firstBlockAfter(ctx, yearStart, currentBlock)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("year [%v] has not started yet", asg.year)
+	}
+
+	endBlock, found, err := asg.firstBlockAfter(ctx, yearEnd, currentBlock)
+	if err != nil {
+		return err
+	}
+	if found {
+		endBlock--
+	} else {
+		endBlock = currentBlock
+		logger.Warnf(
+			"year [%v] has not ended yet; statement covers blocks "+
+				"until the most recent confirmed block [%v]",
+			asg.year,
+			currentBlock,
+		)
+	}
+
+	if endBlock < startBlock {
+		return fmt.Errorf("no confirmed blocks in year [%v]", asg.year)
+	}
+
+	asg.beacon.period.StartBlock = startBlock
+	asg.beacon.period.EndBlock = endBlock
+
+	if err := asg.beacon.FetchCommonData(ctx); err != nil {
+		return err
+	}
+
+	asg.periodStart, err = asg.blockTime(ctx, startBlock)
+	if err != nil {
+		return err
+	}
+
+	asg.periodEnd = asg.beacon.periodEndBlockTime
+
+	return nil
+}
+
+// firstBlockAfter finds the first block mined at or after the given time,
+// up to the given last block. Returns false if there is no such block.
+func (asg *AnnualStatementGenerator) firstBlockAfter(
+	ctx context.Context,
+	at time.Time,
+	lastBlock uint64,
+) (uint64, bool, error) {
+	low, high := uint64(0), lastBlock+1
+
+	for low < high {
+		middle := low + (high-low)/2
+
+		middleTime, err := asg.blockTime(ctx, middle)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if middleTime.Before(at) {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+
+	return low, low <= lastBlock, nil
+}
+
+func (asg *AnnualStatementGenerator) blockTime(
+	ctx context.Context,
+	blockNumber uint64,
+) (time.Time, error) {
+	if blockTime, ok := asg.blockTimes[blockNumber]; ok {
+		return blockTime, nil
+	}
+
+	blockTime, err := asg.dataSource.BlockTime(ctx, blockNumber)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"could not get time of block [%v]: [%v]",
+			blockNumber,
+			err,
+		)
+	}
+
+	asg.blockTimes[blockNumber] = blockTime
+
+	return blockTime, nil
+}
+
+func (asg *AnnualStatementGenerator) Generate(
+	ctx context.Context,
+	customer *Customer,
+) (*AnnualStatement, error) {
+	delegation, err := asg.dataSource.Delegation(ctx, customer.Operator)
+	if err != nil {
+		return nil, fmt.Errorf("could not get delegation info: [%v]", err)
+	}
+
+	if err := resolveCustomerAddresses(customer, delegation); err != nil {
+		return nil, err
+	}
+
+	receivedEvents, err := asg.receivedRewardEvents(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	unlockedEvents, err := asg.unlockedRewardEvents(ctx, customer.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	customerSharePercentage := big.NewFloat(
+		float64(customer.CustomerSharePercentage),
+	)
+
+	receipts, err := asg.valueRewardEvents(
+		ctx,
+		receivedEvents,
+		customerSharePercentage,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	unlockedRewards, err := asg.valueRewardEvents(
+		ctx,
+		unlockedEvents,
+		customerSharePercentage,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &AnnualStatement{
+		Customer:         customer,
+		Year:             asg.year,
+		Currency:         asg.currency,
+		PeriodStartBlock: asg.beacon.period.StartBlock,
+		PeriodEndBlock:   asg.beacon.period.EndBlock,
+		PeriodStartDate:  formatTime(asg.periodStart),
+		PeriodEndDate:    formatTime(asg.periodEnd),
+		Receipts:         receipts,
+		UnlockedRewards:  unlockedRewards,
+	}
+
+	statement.Totals, statement.TotalValue, statement.CustomerShareTotalValue =
+		sumUpRewardReceipts(receipts)
+
+	unlockedTotals, unlockedTotalValue, _ := sumUpRewardReceipts(unlockedRewards)
+	statement.UnlockedRewardsTotal = big.NewFloat(0).Text('f', 6)
+	if len(unlockedTotals) > 0 {
+		statement.UnlockedRewardsTotal = unlockedTotals[0].Amount
+	}
+	statement.UnlockedRewardsTotalValue = unlockedTotalValue
+
+	return statement, nil
+}
+
+// receivedRewardEvents lists ETH rewards withdrawn to the beneficiary and
+// reward tokens distributed to it during the year, ordered by block.
+func (asg *AnnualStatementGenerator) receivedRewardEvents(
+	ctx context.Context,
+	customer *Customer,
+) ([]*rewardEvent, error) {
+	period := asg.beacon.period
+	events := make([]*rewardEvent, 0)
+
+	for _, operatorContract := range asg.beacon.operatorContracts {
+		withdrawals, err := asg.dataSource.RewardsWithdrawals(
+			ctx,
+			operatorContract,
+			customer.Beneficiary,
+			period.StartBlock,
+			period.EndBlock,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not get rewards withdrawals: [%v]", err)
+		}
+
+		for _, withdrawal := range withdrawals {
+			if !strings.EqualFold(withdrawal.Operator, customer.Operator) {
+				continue
+			}
+
+			events = append(events, &rewardEvent{
+				kind:        RewardsWithdrawn,
+				blockNumber: withdrawal.BlockNumber,
+				txHash:      withdrawal.TxHash,
+				symbol:      "ETH",
+				amount:      withdrawal.Amount,
+			})
+		}
+	}
+
+	keepTransfers, err := asg.dataSource.IncomingKeepTransfers(
+		ctx,
+		customer.Beneficiary,
+		asg.beacon.keepRewardDistributors,
+		period.StartBlock,
+		period.EndBlock,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get KEEP reward transfers: [%v]", err)
+	}
+
+	for _, transfer := range keepTransfers {
+		events = append(events, &rewardEvent{
+			kind:        RewardsDistributed,
+			blockNumber: transfer.BlockNumber,
+			txHash:      transfer.TxHash,
+			symbol:      "KEEP",
+			amount:      transfer.Amount,
+		})
+	}
+
+	for _, rewardToken := range asg.beacon.rewardTokens {
+		symbol, err := asg.dataSource.TokenSymbol(ctx, rewardToken.Address)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not get symbol of token [%v]: [%v]",
+				rewardToken.Address,
+				err,
+			)
+		}
+
+		transfers, err := asg.dataSource.IncomingTokenTransfers(
+			ctx,
+			rewardToken.Address,
+			customer.Beneficiary,
+			rewardToken.Distributors,
+			period.StartBlock,
+			period.EndBlock,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not get %v reward transfers: [%v]",
+				symbol,
+				err,
+			)
+		}
+
+		for _, transfer := range transfers {
+			events = append(events, &rewardEvent{
+				kind:        RewardsDistributed,
+				blockNumber: transfer.BlockNumber,
+				txHash:      transfer.TxHash,
+				symbol:      symbol,
+				amount:      transfer.Amount,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].blockNumber < events[j].blockNumber
+	})
+
+	return events, nil
+}
+
+// unlockedRewardEvents lists ETH rewards of the operator's members in groups
+// which expired during the year, ordered by block.
+func (asg *AnnualStatementGenerator) unlockedRewardEvents(
+	ctx context.Context,
+	operator string,
+) ([]*rewardEvent, error) {
+	period := asg.beacon.period
+	events := make([]*rewardEvent, 0)
+
+	for _, group := range asg.beacon.groups {
+		if group.staleBlock < period.StartBlock ||
+			group.staleBlock > period.EndBlock {
+			continue
+		}
+
+		operatorMembers := getGroupMemberIndexes(operator, group)
+		if len(operatorMembers) == 0 {
+			continue
+		}
+
+		memberRewards, err := asg.dataSource.GroupMemberRewards(
+			ctx,
+			group.operatorContract,
+			group.publicKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		groupRewardsWei := new(big.Int).Mul(
+			memberRewards,
+			big.NewInt(int64(len(operatorMembers))),
+		)
+
+		events = append(events, &rewardEvent{
+			kind:        RewardsUnlocked,
+			blockNumber: group.staleBlock,
+			symbol:      "ETH",
+			amount:      chain.WeiToEth(groupRewardsWei),
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].blockNumber < events[j].blockNumber
+	})
+
+	return events, nil
+}
+
+// valueRewardEvents values each event and the customer's share of it at
+// the token price at the time of the event block.
+func (asg *AnnualStatementGenerator) valueRewardEvents(
+	ctx context.Context,
+	events []*rewardEvent,
+	customerSharePercentage *big.Float,
+) ([]*RewardReceipt, error) {
+	receipts := make([]*RewardReceipt, 0, len(events))
+
+	for _, event := range events {
+		eventTime, err := asg.blockTime(ctx, event.blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		price, err := asg.prices.Price(ctx, event.symbol, eventTime)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not get %v price at [%v]: [%v]",
+				event.symbol,
+				formatTime(eventTime),
+				err,
+			)
+		}
+
+		// as in billings, ETH withdrawn to the beneficiary is entirely the
+		// customer's and only the other rewards are split
+		customerShare := event.amount
+		if event.kind != RewardsWithdrawn {
+			customerShare, _ = splitRewards(customerSharePercentage, event.amount)
+		}
+
+		receipts = append(receipts, &RewardReceipt{
+			Kind:               event.kind,
+			BlockNumber:        event.blockNumber,
+			Date:               formatTime(eventTime),
+			TxHash:             event.txHash,
+			Symbol:             event.symbol,
+			Amount:             event.amount.Text('f', 6),
+			CustomerShare:      customerShare.Text('f', 6),
+			Price:              price.Text('f', 6),
+			Value:              new(big.Float).Mul(event.amount, price).Text('f', 2),
+			CustomerShareValue: new(big.Float).Mul(customerShare, price).Text('f', 2),
+		})
+	}
+
+	return receipts, nil
+}
+
+// sumUpRewardReceipts returns totals of each token, in the order tokens
+// first appear, and the total value of all receipts and of the customer's
+// shares.
+func sumUpRewardReceipts(
+	receipts []*RewardReceipt,
+) ([]*RewardTotal, string, string) {
+	type sums struct {
+		amount, customerShare, value, customerShareValue *big.Float
+	}
+
+	symbols := make([]string, 0)
+	symbolSums := make(map[string]*sums)
+	totalValue := big.NewFloat(0)
+	customerShareTotalValue := big.NewFloat(0)
+
+	add := func(sum *big.Float, value string) *big.Float {
+		parsed, _ := new(big.Float).SetString(value)
+		if parsed == nil {
+			return sum
+		}
+		return new(big.Float).Add(sum, parsed)
+	}
+
+	for _, receipt := range receipts {
+		sum, ok := symbolSums[receipt.Symbol]
+		if !ok {
+			sum = &sums{
+				big.NewFloat(0),
+				big.NewFloat(0),
+				big.NewFloat(0),
+				big.NewFloat(0),
+			}
+			symbolSums[receipt.Symbol] = sum
+			symbols = append(symbols, receipt.Symbol)
+		}
+
+		sum.amount = add(sum.amount, receipt.Amount)
+		sum.customerShare = add(sum.customerShare, receipt.CustomerShare)
+		sum.value = add(sum.value, receipt.Value)
+		sum.customerShareValue = add(sum.customerShareValue, receipt.CustomerShareValue)
+
+		totalValue = add(totalValue, receipt.Value)
+		customerShareTotalValue = add(customerShareTotalValue, receipt.CustomerShareValue)
+	}
+
+	totals := make([]*RewardTotal, len(symbols))
+	for i, symbol := range symbols {
+		sum := symbolSums[symbol]
+		totals[i] = &RewardTotal{
+			Symbol:             symbol,
+			Amount:             sum.amount.Text('f', 6),
+			CustomerShare:      sum.customerShare.Text('f', 6),
+			Value:              sum.value.Text('f', 2),
+			CustomerShareValue: sum.customerShareValue.Text('f', 2),
+		}
+	}
+
+	return totals, totalValue.Text('f', 2), customerShareTotalValue.Text('f', 2)
+}
+
+// Records returns all reward receipts and unlocked rewards as records
+// ready to be written to a CSV file, including the header record.
+func (as *AnnualStatement) Records() [][]string {
+	records := [][]string{
+		{
+			"Kind",
+			"Block",
+			"Date",
+			"Transaction",
+			"Token",
+			"Amount",
+			"Customer share",
+			"Price (" + as.Currency + ")",
+			"Value (" + as.Currency + ")",
+			"Customer share value (" + as.Currency + ")",
+		},
+	}
+
+	receipts := append(
+		append([]*RewardReceipt{}, as.Receipts...),
+		as.UnlockedRewards...,
+	)
+
+	for _, receipt := range receipts {
+		records = append(records, []string{
+			receipt.Kind,
+			strconv.FormatUint(receipt.BlockNumber, 10),
+			receipt.Date,
+			receipt.TxHash,
+			receipt.Symbol,
+			receipt.Amount,
+			receipt.CustomerShare,
+			receipt.Price,
+			receipt.Value,
+			receipt.CustomerShareValue,
+		})
+	}
+
+	return records
+}
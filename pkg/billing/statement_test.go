@@ -0,0 +1,200 @@
+package billing
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// genesisTime is the time of block 0 of blockTimeDataSource.
+var genesisTime = time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC)
+
+// blockTimeDataSource mines a block every 15 minutes since the genesis.
+type blockTimeDataSource struct {
+	BeaconDataSource
+}
+
+func (btds *blockTimeDataSource) BlockTime(
+	ctx context.Context,
+	blockNumber uint64,
+) (time.Time, error) {
+	return genesisTime.Add(time.Duration(blockNumber) * 15 * time.Minute), nil
+}
+
+type fixedPriceSource map[string]*big.Float
+
+func (fps fixedPriceSource) Price(
+	ctx context.Context,
+	symbol string,
+	at time.Time,
+) (*big.Float, error) {
+	return fps[symbol], nil
+}
+
+func TestFirstBlockAfter(t *testing.T) {
+	asg := &AnnualStatementGenerator{
+		dataSource: &blockTimeDataSource{},
+		blockTimes: make(map[uint64]time.Time),
+	}
+
+	var tests = map[string]struct {
+		at            time.Time
+		lastBlock     uint64
+		expectedBlock uint64
+		expectedFound bool
+	}{
+		"time of a block": {
+			at:            time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			lastBlock:     1000,
+			expectedBlock: 4,
+			expectedFound: true,
+		},
+		"time between blocks": {
+			at:            time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC),
+			lastBlock:     1000,
+			expectedBlock: 5,
+			expectedFound: true,
+		},
+		"before genesis": {
+			at:            time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			lastBlock:     1000,
+			expectedBlock: 0,
+			expectedFound: true,
+		},
+		"after the last block": {
+			at:            time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			lastBlock:     1000,
+			expectedFound: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			block, found, err := asg.firstBlockAfter(
+				context.Background(),
+				test.at,
+				test.lastBlock,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if found != test.expectedFound ||
+				(found && block != test.expectedBlock) {
+				t.Errorf(
+					"unexpected block\nexpected: [%v %v]\nactual:   [%v %v]",
+					test.expectedBlock,
+					test.expectedFound,
+					block,
+					found,
+				)
+			}
+		})
+	}
+}
+
+func TestValueRewardEvents(t *testing.T) {
+	asg := &AnnualStatementGenerator{
+		dataSource: &blockTimeDataSource{},
+		prices: fixedPriceSource{
+			"ETH":  big.NewFloat(2000),
+			"KEEP": big.NewFloat(0.5),
+		},
+		blockTimes: make(map[uint64]time.Time),
+	}
+
+	events := []*rewardEvent{
+		{kind: RewardsWithdrawn, blockNumber: 4, symbol: "ETH", amount: big.NewFloat(0.5)},
+		{kind: RewardsDistributed, blockNumber: 8, symbol: "KEEP", amount: big.NewFloat(100)},
+		{kind: RewardsDistributed, blockNumber: 12, symbol: "KEEP", amount: big.NewFloat(50)},
+	}
+
+	receipts, err := asg.valueRewardEvents(
+		context.Background(),
+		events,
+		big.NewFloat(80),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedReceipts := []*RewardReceipt{
+		{
+			Kind:               RewardsWithdrawn,
+			BlockNumber:        4,
+			Date:               "2026-01-01 00:00 UTC",
+			Symbol:             "ETH",
+			Amount:             "0.500000",
+			CustomerShare:      "0.500000",
+			Price:              "2000.000000",
+			Value:              "1000.00",
+			CustomerShareValue: "1000.00",
+		},
+		{
+			Kind:               RewardsDistributed,
+			BlockNumber:        8,
+			Date:               "2026-01-01 01:00 UTC",
+			Symbol:             "KEEP",
+			Amount:             "100.000000",
+			CustomerShare:      "80.000000",
+			Price:              "0.500000",
+			Value:              "50.00",
+			CustomerShareValue: "40.00",
+		},
+		{
+			Kind:               RewardsDistributed,
+			BlockNumber:        12,
+			Date:               "2026-01-01 02:00 UTC",
+			Symbol:             "KEEP",
+			Amount:             "50.000000",
+			CustomerShare:      "40.000000",
+			Price:              "0.500000",
+			Value:              "25.00",
+			CustomerShareValue: "20.00",
+		},
+	}
+
+	if !reflect.DeepEqual(expectedReceipts, receipts) {
+		for i, receipt := range receipts {
+			t.Logf("receipt [%v]: [%+v]", i, receipt)
+		}
+		t.Errorf("unexpected receipts")
+	}
+
+	totals, totalValue, customerShareTotalValue := sumUpRewardReceipts(receipts)
+
+	expectedTotals := []*RewardTotal{
+		{
+			Symbol:             "ETH",
+			Amount:             "0.500000",
+			CustomerShare:      "0.500000",
+			Value:              "1000.00",
+			CustomerShareValue: "1000.00",
+		},
+		{
+			Symbol:             "KEEP",
+			Amount:             "150.000000",
+			CustomerShare:      "120.000000",
+			Value:              "75.00",
+			CustomerShareValue: "60.00",
+		},
+	}
+
+	if !reflect.DeepEqual(expectedTotals, totals) {
+		for i, total := range totals {
+			t.Logf("total [%v]: [%+v]", i, total)
+		}
+		t.Errorf("unexpected totals")
+	}
+
+	if totalValue != "1075.00" || customerShareTotalValue != "1060.00" {
+		t.Errorf(
+			"unexpected total values\nexpected: [1075.00 1060.00]\n"+
+				"actual:   [%v %v]",
+			totalValue,
+			customerShareTotalValue,
+		)
+	}
+}
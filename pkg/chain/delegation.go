@@ -0,0 +1,107 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type Delegation struct {
+	Owner       string
+	Beneficiary string
+	Authorizer  string
+	Amount      *big.Float
+
+	CreatedAt time.Time
+	// zero if the stake has not been undelegated
+	UndelegatedAt time.Time
+
+	// set if the operator is authorized for all known operator contracts
+	IsOperatorContractAuthorized bool
+	Locks                        []*StakeLock
+}
+
+// StakeLock is a lock put on the delegated stake by an operator contract
+// which prevents the stake from being recovered until the lock expires.
+type StakeLock struct {
+	Creator    string
+	Expiration time.Time
+}
+
+// Delegation returns the full information about the delegation of the given
+// operator kept by the staking contract.
+func (ec *EthereumClient) Delegation(
+	ctx context.Context,
+	operator string,
+) (*Delegation, error) {
+	operatorAddress := common.HexToAddress(operator)
+
+	info, err := ec.tokenStaking.GetDelegationInfo(ec.callOpts(ctx), operatorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := ec.tokenStaking.OwnerOf(ec.callOpts(ctx), operatorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiary, err := ec.tokenStaking.BeneficiaryOf(ec.callOpts(ctx), operatorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizer, err := ec.tokenStaking.AuthorizerOf(ec.callOpts(ctx), operatorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	isAuthorized := true
+	for _, operatorContract := range ec.OperatorContracts() {
+		isAuthorizedForContract, err := ec.tokenStaking.IsAuthorizedForOperator(
+			ec.callOpts(ctx),
+			operatorAddress,
+			common.HexToAddress(operatorContract),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		isAuthorized = isAuthorized && isAuthorizedForContract
+	}
+
+	locks, err := ec.tokenStaking.GetLocks(ec.callOpts(ctx), operatorAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	stakeLocks := make([]*StakeLock, len(locks.Creators))
+	for i, creator := range locks.Creators {
+		stakeLocks[i] = &StakeLock{
+			Creator:    creator.Hex(),
+			Expiration: unixTime(locks.Expirations[i]),
+		}
+	}
+
+	undelegatedAt := time.Time{}
+	if info.UndelegatedAt.Sign() > 0 {
+		undelegatedAt = unixTime(info.UndelegatedAt)
+	}
+
+	return &Delegation{
+		Owner:                        owner.Hex(),
+		Beneficiary:                  beneficiary.Hex(),
+		Authorizer:                   authorizer.Hex(),
+		Amount:                       ec.keepToken.toUnits(info.Amount),
+		CreatedAt:                    unixTime(info.CreatedAt),
+		UndelegatedAt:                undelegatedAt,
+		IsOperatorContractAuthorized: isAuthorized,
+		Locks:                        stakeLocks,
+	}, nil
+}
+
+func unixTime(timestamp *big.Int) time.Time {
+	return time.Unix(timestamp.Int64(), 0)
+}
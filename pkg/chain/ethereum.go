@@ -2,19 +2,40 @@ package chain
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/ipfs/go-log"
 
 	coreabi "github.com/boar-network/keep-billings/pkg/chain/gen/core/abi"
-	erc20abi "github.com/boar-network/keep-billings/pkg/chain/gen/erc20/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 var logger = log.Logger("billings-ethereum")
 
+// defaultGroupActiveTime is the number of blocks a beacon group stays
+// active after its registration, as assigned in the KeepRandomBeaconOperator
+// constructor (14 days in 15s blocks). It's used only for operator contracts
+// not exposing the groupActiveTime getter.
+const defaultGroupActiveTime = 86400 * 14 / 15
+
+// groupActiveTimeABI describes the groupActiveTime getter of the operator
+// contract, which is not part of all generated operator contract bindings.
+const groupActiveTimeABI = `[{
+	"constant": true,
+	"inputs": [],
+	"name": "groupActiveTime",
+	"outputs": [{"name": "", "type": "uint256"}],
+	"payable": false,
+	"stateMutability": "view",
+	"type": "function"
+}]`
+
 var methodLookupAbiStrings = []string{
 	coreabi.TokenStakingABI,
 	coreabi.KeepRandomBeaconOperatorABI,
@@ -22,26 +43,63 @@ var methodLookupAbiStrings = []string{
 }
 
 type EthereumClient struct {
-	client           *ethclient.Client
-	keepToken        *erc20abi.TokenCaller
-	tokenStaking     *coreabi.TokenStakingCaller
-	operatorContract *coreabi.KeepRandomBeaconOperatorCaller
+	client                  *FailoverClient
+	tokens                  map[common.Address]*token
+	keepToken               *token
+	tokenStaking            *coreabi.TokenStakingCaller
+	tokenStakingFilterer    *coreabi.TokenStakingFilterer
+	tokenStakingAddress     common.Address
+	operatorContractAddress common.Address
+	// all known beacon operator contracts, ordered from the oldest one
+	operatorContracts []*operatorContract
+	// logs serves event queries not covered by the log indexer in chunks
+	logs *chunkedLogSource
+
+	// confirmations is the number of blocks mined on top of a block after
+	// which the block is considered final
+	confirmations uint64
+	// pinnedBlock is the block state reads are made against; the latest
+	// block is used if not set
+	pinnedBlock *big.Int
 }
 
 func NewEthereumClient(
-	url string,
+	ctx context.Context,
+	endpoints []Endpoint,
+	callTimeout time.Duration,
+	chainID uint64,
 	keepTokenAddress string,
 	tokenStakingAddress string,
 	operatorContractAddress string,
+	confirmations uint64,
+	maxChunkSize uint64,
 ) (*EthereumClient, error) {
-	client, err := ethclient.Dial(url)
+	client, err := NewFailoverClient(ctx, endpoints, callTimeout, chainID)
 	if err != nil {
 		return nil, err
 	}
 
-	keepToken, err := erc20abi.NewTokenCaller(
+	logs := newChunkedLogSource(client, maxChunkSize)
+
+	for _, address := range []string{
+		keepTokenAddress,
+		tokenStakingAddress,
+		operatorContractAddress,
+	} {
+		if err := verifyContractCode(
+			ctx,
+			client,
+			common.HexToAddress(address),
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	keepToken, err := newToken(
+		ctx,
 		common.HexToAddress(keepTokenAddress),
 		client,
+		logs,
 	)
 	if err != nil {
 		return nil, err
@@ -55,37 +113,148 @@ func NewEthereumClient(
 		return nil, err
 	}
 
-	operatorContract, err := coreabi.NewKeepRandomBeaconOperatorCaller(
+	tokenStakingFilterer, err := coreabi.NewTokenStakingFilterer(
+		common.HexToAddress(tokenStakingAddress),
+		logs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	configuredOperatorContract, err := newOperatorContract(
 		common.HexToAddress(operatorContractAddress),
 		client,
+		logs,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	return &EthereumClient{
-		client:           client,
-		keepToken:        keepToken,
-		tokenStaking:     tokenStaking,
-		operatorContract: operatorContract,
+		client:                  client,
+		logs:                    logs,
+		tokens:                  map[common.Address]*token{keepToken.address: keepToken},
+		keepToken:               keepToken,
+		tokenStaking:            tokenStaking,
+		tokenStakingFilterer:    tokenStakingFilterer,
+		tokenStakingAddress:     common.HexToAddress(tokenStakingAddress),
+		operatorContractAddress: common.HexToAddress(operatorContractAddress),
+		operatorContracts:       []*operatorContract{configuredOperatorContract},
+		confirmations:           confirmations,
 	}, nil
 }
 
-func (ec *EthereumClient) KeepBalance(address string) (*big.Float, error) {
-	balance, err := ec.keepToken.BalanceOf(nil, common.HexToAddress(address))
+// verifyContractCode makes sure there is a contract deployed at the given
+// address, so a mistyped address or an address from another network is
+// reported at start-up instead of producing empty reports.
+func verifyContractCode(
+	ctx context.Context,
+	client *FailoverClient,
+	address common.Address,
+) error {
+	code, err := client.CodeAt(ctx, address, nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf(
+			"could not get code of contract [%v]: [%v]",
+			address.Hex(),
+			err,
+		)
+	}
+
+	if len(code) == 0 {
+		return fmt.Errorf("no contract code at address [%v]", address.Hex())
+	}
+
+	return nil
+}
+
+// EnableLogIndexer makes the client read events of the staking contract
+// and all known operator contracts from the local log store synced incrementally
+// with the chain instead of querying the whole block range from the chain
+// each time.
+func (ec *EthereumClient) EnableLogIndexer(
+	storeFile string,
+	startBlock uint64,
+) error {
+	addresses := []common.Address{ec.tokenStakingAddress}
+	for _, operatorContract := range ec.operatorContracts {
+		addresses = append(addresses, operatorContract.address)
+	}
+
+	indexer, err := NewLogIndexer(
+		ec.client,
+		storeFile,
+		addresses,
+		startBlock,
+		ec.logs.maxChunkSize,
+		ec.confirmations,
+	)
+	if err != nil {
+		return err
+	}
+
+	tokenStakingFilterer, err := coreabi.NewTokenStakingFilterer(
+		ec.tokenStakingAddress,
+		indexer,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, operatorContract := range ec.operatorContracts {
+		filterer, err := coreabi.NewKeepRandomBeaconOperatorFilterer(
+			operatorContract.address,
+			indexer,
+		)
+		if err != nil {
+			return err
+		}
+
+		operatorContract.filterer = filterer
 	}
 
-	// it's not ETH but KEEP ERC-20 uses the same number of decimals
-	return WeiToEth(balance), nil
+	ec.tokenStakingFilterer = tokenStakingFilterer
+
+	return nil
+}
+
+func (ec *EthereumClient) KeepBalance(
+	ctx context.Context,
+	address string,
+) (*big.Float, error) {
+	return ec.keepToken.balanceOf(ec.callOpts(ctx), address)
+}
+
+// KeepBalanceAt returns the KEEP balance of the address at the given block.
+// The balance is zero before the token contract was deployed. Reading state
+// of past blocks requires an archive node.
+func (ec *EthereumClient) KeepBalanceAt(
+	ctx context.Context,
+	address string,
+	blockNumber uint64,
+) (*big.Float, error) {
+	balance, err := ec.keepToken.balanceOf(
+		&bind.CallOpts{
+			Context:     ctx,
+			BlockNumber: new(big.Int).SetUint64(blockNumber),
+		},
+		address,
+	)
+	if err == bind.ErrNoCode {
+		return big.NewFloat(0), nil
+	}
+
+	return balance, err
 }
 
-func (ec *EthereumClient) EthBalance(address string) (*big.Float, error) {
+func (ec *EthereumClient) EthBalance(
+	ctx context.Context,
+	address string,
+) (*big.Float, error) {
 	weiBalance, err := ec.client.BalanceAt(
-		context.Background(),
+		ctx,
 		common.HexToAddress(address),
-		nil,
+		ec.pinnedBlock,
 	)
 	if err != nil {
 		return nil, err
@@ -94,18 +263,104 @@ func (ec *EthereumClient) EthBalance(address string) (*big.Float, error) {
 	return WeiToEth(weiBalance), nil
 }
 
-func (ec *EthereumClient) Stake(address string) (*big.Float, error) {
-	stake, err := ec.tokenStaking.BalanceOf(nil, common.HexToAddress(address))
+func (ec *EthereumClient) Stake(
+	ctx context.Context,
+	address string,
+) (*big.Float, error) {
+	stake, err := ec.tokenStaking.BalanceOf(ec.callOpts(ctx), common.HexToAddress(address))
 	if err != nil {
 		return nil, err
 	}
 
-	// it's not ETH but KEEP ERC-20 uses the same number of decimals
-	return WeiToEth(stake), nil
+	return ec.keepToken.toUnits(stake), nil
 }
 
-func (ec *EthereumClient) AllGroupsCount() (int64, error) {
-	result, err := ec.operatorContract.GetNumberOfCreatedGroups(nil)
+// StakeAt returns the stake of the operator at the given block. The stake
+// is zero before the staking contract was deployed, e.g. for periods
+// starting at the genesis block. Reading state of past blocks requires an
+// archive node.
+func (ec *EthereumClient) StakeAt(
+	ctx context.Context,
+	address string,
+	blockNumber uint64,
+) (*big.Float, error) {
+	stake, err := ec.tokenStaking.BalanceOf(
+		&bind.CallOpts{
+			Context:     ctx,
+			BlockNumber: new(big.Int).SetUint64(blockNumber),
+		},
+		common.HexToAddress(address),
+	)
+	if err == bind.ErrNoCode {
+		return big.NewFloat(0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ec.keepToken.toUnits(stake), nil
+}
+
+// CurrentBlock returns the most recent block having the configured number
+// of confirmations.
+func (ec *EthereumClient) CurrentBlock(ctx context.Context) (uint64, error) {
+	header, err := ec.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return confirmedBlock(header.Number.Uint64(), ec.confirmations), nil
+}
+
+// PinBlock makes all subsequent state reads which don't take an explicit
+// block return the state as of the given block instead of the latest one.
+func (ec *EthereumClient) PinBlock(blockNumber uint64) {
+	ec.pinnedBlock = new(big.Int).SetUint64(blockNumber)
+}
+
+func (ec *EthereumClient) callOpts(ctx context.Context) *bind.CallOpts {
+	return &bind.CallOpts{Context: ctx, BlockNumber: ec.pinnedBlock}
+}
+
+func confirmedBlock(headBlock uint64, confirmations uint64) uint64 {
+	if headBlock < confirmations {
+		return 0
+	}
+
+	return headBlock - confirmations
+}
+
+func (ec *EthereumClient) BlockTime(
+	ctx context.Context,
+	blockNumber uint64,
+) (time.Time, error) {
+	header, err := ec.client.HeaderByNumber(
+		ctx,
+		new(big.Int).SetUint64(blockNumber),
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// EndpointsStats returns the summary of calls served by each configured
+// Ethereum endpoint.
+func (ec *EthereumClient) EndpointsStats() []*EndpointStats {
+	return ec.client.Stats()
+}
+
+func (ec *EthereumClient) AllGroupsCount(
+	ctx context.Context,
+	operatorContractAddress string,
+) (int64, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := operatorContract.caller.GetNumberOfCreatedGroups(ec.callOpts(ctx))
 	if err != nil {
 		return 0, err
 	}
@@ -113,8 +368,16 @@ func (ec *EthereumClient) AllGroupsCount() (int64, error) {
 	return result.Int64(), nil
 }
 
-func (ec *EthereumClient) ActiveGroupsCount() (int64, error) {
-	result, err := ec.operatorContract.NumberOfGroups(nil)
+func (ec *EthereumClient) ActiveGroupsCount(
+	ctx context.Context,
+	operatorContractAddress string,
+) (int64, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := operatorContract.caller.NumberOfGroups(ec.callOpts(ctx))
 	if err != nil {
 		return 0, err
 	}
@@ -122,8 +385,16 @@ func (ec *EthereumClient) ActiveGroupsCount() (int64, error) {
 	return result.Int64(), nil
 }
 
-func (ec *EthereumClient) FirstActiveGroupIndex() (int64, error) {
-	result, err := ec.operatorContract.GetFirstActiveGroupIndex(nil)
+func (ec *EthereumClient) FirstActiveGroupIndex(
+	ctx context.Context,
+	operatorContractAddress string,
+) (int64, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := operatorContract.caller.GetFirstActiveGroupIndex(ec.callOpts(ctx))
 	if err != nil {
 		return 0, err
 	}
@@ -131,14 +402,136 @@ func (ec *EthereumClient) FirstActiveGroupIndex() (int64, error) {
 	return result.Int64(), nil
 }
 
-func (ec *EthereumClient) GroupPublicKey(groupIndex int64) ([]byte, error) {
-	return ec.operatorContract.GetGroupPublicKey(nil, big.NewInt(groupIndex))
+func (ec *EthereumClient) GroupPublicKey(
+	ctx context.Context,
+	operatorContractAddress string,
+	groupIndex int64,
+) ([]byte, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return operatorContract.caller.GetGroupPublicKey(
+		ec.callOpts(ctx),
+		big.NewInt(groupIndex),
+	)
+}
+
+func (ec *EthereumClient) GroupRegistrationBlock(
+	ctx context.Context,
+	operatorContractAddress string,
+	groupIndex int64,
+) (uint64, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := operatorContract.caller.GetGroupRegistrationBlockHeight(
+		ec.callOpts(ctx),
+		big.NewInt(groupIndex),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Uint64(), nil
+}
+
+// GroupLifetime returns the number of blocks after which a registered group
+// becomes stale, that is, it's expired and can no longer be selected for
+// any operation, including the ones requested just before its expiration.
+func (ec *EthereumClient) GroupLifetime(
+	ctx context.Context,
+	operatorContractAddress string,
+) (uint64, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	groupActiveTime, err := ec.groupActiveTime(ctx, operatorContract)
+	if err != nil {
+		return 0, err
+	}
+
+	relayEntryTimeout, err := operatorContract.caller.RelayEntryTimeout(
+		ec.callOpts(ctx),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return groupActiveTime + relayEntryTimeout.Uint64(), nil
+}
+
+// groupActiveTime returns the number of blocks a group of the operator
+// contract stays active after its registration. The value is read from the
+// contract once and the default one is used if the contract doesn't expose
+// it.
+func (ec *EthereumClient) groupActiveTime(
+	ctx context.Context,
+	operatorContract *operatorContract,
+) (uint64, error) {
+	if operatorContract.groupActiveTime > 0 {
+		return operatorContract.groupActiveTime, nil
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(groupActiveTimeABI))
+	if err != nil {
+		return 0, err
+	}
+
+	contract := bind.NewBoundContract(
+		operatorContract.address,
+		parsedABI,
+		ec.client,
+		nil,
+		nil,
+	)
+
+	result := new(*big.Int)
+	if err := contract.Call(
+		ec.callOpts(ctx),
+		result,
+		"groupActiveTime",
+	); err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+
+		logger.Warnf(
+			"could not read group active time of operator contract [%v], "+
+				"using the default of [%v] blocks: [%v]",
+			operatorContract.address.Hex(),
+			defaultGroupActiveTime,
+			err,
+		)
+
+		operatorContract.groupActiveTime = defaultGroupActiveTime
+		return operatorContract.groupActiveTime, nil
+	}
+
+	operatorContract.groupActiveTime = (*result).Uint64()
+
+	return operatorContract.groupActiveTime, nil
 }
 
 func (ec *EthereumClient) GroupMembers(
+	ctx context.Context,
+	operatorContractAddress string,
 	groupPublicKey []byte,
 ) (map[int]string, error) {
-	addresses, err := ec.operatorContract.GetGroupMembers(nil, groupPublicKey)
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := operatorContract.caller.GetGroupMembers(
+		ec.callOpts(ctx),
+		groupPublicKey,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -152,17 +545,34 @@ func (ec *EthereumClient) GroupMembers(
 }
 
 func (ec *EthereumClient) GroupMemberRewards(
+	ctx context.Context,
+	operatorContractAddress string,
 	groupPublicKey []byte,
 ) (*big.Int, error) {
-	return ec.operatorContract.GetGroupMemberRewards(nil, groupPublicKey)
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return operatorContract.caller.GetGroupMemberRewards(
+		ec.callOpts(ctx),
+		groupPublicKey,
+	)
 }
 
 func (ec *EthereumClient) AreRewardsWithdrawn(
+	ctx context.Context,
+	operatorContractAddress string,
 	operator string,
 	groupIndex int64,
 ) (bool, error) {
-	return ec.operatorContract.HasWithdrawnRewards(
-		nil,
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return false, err
+	}
+
+	return operatorContract.caller.HasWithdrawnRewards(
+		ec.callOpts(ctx),
 		common.HexToAddress(operator),
 		big.NewInt(groupIndex),
 	)
@@ -0,0 +1,324 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type RelayEntryEventType int
+
+const (
+	RelayEntryRequested RelayEntryEventType = iota
+	RelayEntrySubmitted
+	RelayEntryTimedOut
+)
+
+// RelayEntryEvent is one of the events emitted by the operator contract
+// over the lifecycle of a relay entry. Only one relay entry can be in
+// progress at a time so the submission or timeout always refers to the
+// most recent request.
+type RelayEntryEvent struct {
+	Type             RelayEntryEventType
+	OperatorContract string
+	BlockNumber      uint64
+	LogIndex         uint
+	TxHash           string
+
+	// set only for the relay entry requested event
+	GroupPublicKey []byte
+	// set only for the relay entry timed out event
+	GroupIndex int64
+}
+
+type StakePenaltyType int
+
+const (
+	StakeSlashed StakePenaltyType = iota
+	StakeSeized
+)
+
+// StakePenalty is a punishment of the operator executed by the staking
+// contract. Slashed tokens are burned while seized tokens are burned with
+// a part of them given to the tattletale as a reward.
+type StakePenalty struct {
+	Type        StakePenaltyType
+	BlockNumber uint64
+	LogIndex    uint
+	TxHash      string
+	Amount      *big.Float
+}
+
+// RewardsWithdrawal is a withdrawal of the operator's group member rewards
+// from the operator contract to the beneficiary of the operator.
+type RewardsWithdrawal struct {
+	OperatorContract string
+	BlockNumber      uint64
+	TxHash           string
+	Beneficiary      string
+	Operator         string
+	GroupIndex       int64
+	Amount           *big.Float
+}
+
+type DkgResultSubmission struct {
+	OperatorContract string
+	BlockNumber      uint64
+	TxHash           string
+	MemberIndex      int
+	GroupPublicKey   []byte
+}
+
+// RelayEntryEvents returns all relay entry requests, submissions and
+// timeouts emitted by the given operator contract in the given block range,
+// ordered as they were emitted.
+func (ec *EthereumClient) RelayEntryEvents(
+	ctx context.Context,
+	operatorContractAddress string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*RelayEntryEvent, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*RelayEntryEvent, 0)
+
+	requested, err := operatorContract.filterer.FilterRelayEntryRequested(
+		filterOpts(ctx, startBlock, endBlock),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer requested.Close()
+
+	for requested.Next() {
+		events = append(events, &RelayEntryEvent{
+			Type:             RelayEntryRequested,
+			OperatorContract: operatorContract.address.Hex(),
+			BlockNumber:      requested.Event.Raw.BlockNumber,
+			LogIndex:         requested.Event.Raw.Index,
+			TxHash:           requested.Event.Raw.TxHash.Hex(),
+			GroupPublicKey:   requested.Event.GroupPublicKey,
+		})
+	}
+	if err := requested.Error(); err != nil {
+		return nil, err
+	}
+
+	submitted, err := operatorContract.filterer.FilterRelayEntrySubmitted(
+		filterOpts(ctx, startBlock, endBlock),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer submitted.Close()
+
+	for submitted.Next() {
+		events = append(events, &RelayEntryEvent{
+			Type:             RelayEntrySubmitted,
+			OperatorContract: operatorContract.address.Hex(),
+			BlockNumber:      submitted.Event.Raw.BlockNumber,
+			LogIndex:         submitted.Event.Raw.Index,
+			TxHash:           submitted.Event.Raw.TxHash.Hex(),
+		})
+	}
+	if err := submitted.Error(); err != nil {
+		return nil, err
+	}
+
+	timedOut, err := operatorContract.filterer.FilterRelayEntryTimeoutReported(
+		filterOpts(ctx, startBlock, endBlock),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer timedOut.Close()
+
+	for timedOut.Next() {
+		events = append(events, &RelayEntryEvent{
+			Type:             RelayEntryTimedOut,
+			OperatorContract: operatorContract.address.Hex(),
+			BlockNumber:      timedOut.Event.Raw.BlockNumber,
+			LogIndex:         timedOut.Event.Raw.Index,
+			TxHash:           timedOut.Event.Raw.TxHash.Hex(),
+			GroupIndex:       timedOut.Event.GroupIndex.Int64(),
+		})
+	}
+	if err := timedOut.Error(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].BlockNumber != events[j].BlockNumber {
+			return events[i].BlockNumber < events[j].BlockNumber
+		}
+		return events[i].LogIndex < events[j].LogIndex
+	})
+
+	return events, nil
+}
+
+// DkgResultSubmissions returns all DKG results submitted to the given
+// operator contract in the given block range.
+func (ec *EthereumClient) DkgResultSubmissions(
+	ctx context.Context,
+	operatorContractAddress string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*DkgResultSubmission, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := operatorContract.filterer.FilterDkgResultSubmittedEvent(
+		filterOpts(ctx, startBlock, endBlock),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	submissions := make([]*DkgResultSubmission, 0)
+	for iterator.Next() {
+		submissions = append(submissions, &DkgResultSubmission{
+			OperatorContract: operatorContract.address.Hex(),
+			BlockNumber:      iterator.Event.Raw.BlockNumber,
+			TxHash:           iterator.Event.Raw.TxHash.Hex(),
+			MemberIndex:      int(iterator.Event.MemberIndex.Int64()),
+			GroupPublicKey:   iterator.Event.GroupPubKey,
+		})
+	}
+	if err := iterator.Error(); err != nil {
+		return nil, err
+	}
+
+	return submissions, nil
+}
+
+// StakePenalties returns all stake slashing and seizure events of the given
+// operator emitted in the given block range, ordered as they were emitted.
+func (ec *EthereumClient) StakePenalties(
+	ctx context.Context,
+	operator string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*StakePenalty, error) {
+	penalties := make([]*StakePenalty, 0)
+
+	operatorFilter := []common.Address{common.HexToAddress(operator)}
+
+	slashed, err := ec.tokenStakingFilterer.FilterTokensSlashed(
+		filterOpts(ctx, startBlock, endBlock),
+		operatorFilter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer slashed.Close()
+
+	for slashed.Next() {
+		penalties = append(penalties, &StakePenalty{
+			Type:        StakeSlashed,
+			BlockNumber: slashed.Event.Raw.BlockNumber,
+			LogIndex:    slashed.Event.Raw.Index,
+			TxHash:      slashed.Event.Raw.TxHash.Hex(),
+			Amount:      ec.keepToken.toUnits(slashed.Event.Amount),
+		})
+	}
+	if err := slashed.Error(); err != nil {
+		return nil, err
+	}
+
+	seized, err := ec.tokenStakingFilterer.FilterTokensSeized(
+		filterOpts(ctx, startBlock, endBlock),
+		operatorFilter,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer seized.Close()
+
+	for seized.Next() {
+		penalties = append(penalties, &StakePenalty{
+			Type:        StakeSeized,
+			BlockNumber: seized.Event.Raw.BlockNumber,
+			LogIndex:    seized.Event.Raw.Index,
+			TxHash:      seized.Event.Raw.TxHash.Hex(),
+			Amount:      ec.keepToken.toUnits(seized.Event.Amount),
+		})
+	}
+	if err := seized.Error(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(penalties, func(i, j int) bool {
+		if penalties[i].BlockNumber != penalties[j].BlockNumber {
+			return penalties[i].BlockNumber < penalties[j].BlockNumber
+		}
+		return penalties[i].LogIndex < penalties[j].LogIndex
+	})
+
+	return penalties, nil
+}
+
+// RewardsWithdrawals returns all group member rewards withdrawals from the
+// given operator contract to the given beneficiary made in the given block
+// range.
+func (ec *EthereumClient) RewardsWithdrawals(
+	ctx context.Context,
+	operatorContractAddress string,
+	beneficiary string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*RewardsWithdrawal, error) {
+	operatorContract, err := ec.getOperatorContract(operatorContractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := operatorContract.filterer.FilterGroupMemberRewardsWithdrawn(
+		filterOpts(ctx, startBlock, endBlock),
+		[]common.Address{common.HexToAddress(beneficiary)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	withdrawals := make([]*RewardsWithdrawal, 0)
+	for iterator.Next() {
+		withdrawals = append(withdrawals, &RewardsWithdrawal{
+			OperatorContract: operatorContract.address.Hex(),
+			BlockNumber:      iterator.Event.Raw.BlockNumber,
+			TxHash:           iterator.Event.Raw.TxHash.Hex(),
+			Beneficiary:      iterator.Event.Beneficiary.Hex(),
+			Operator:         iterator.Event.Operator.Hex(),
+			GroupIndex:       iterator.Event.GroupIndex.Int64(),
+			Amount:           WeiToEth(iterator.Event.Amount),
+		})
+	}
+	if err := iterator.Error(); err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func filterOpts(
+	ctx context.Context,
+	startBlock uint64,
+	endBlock uint64,
+) *bind.FilterOpts {
+	return &bind.FilterOpts{
+		Start:   startBlock,
+		End:     &endBlock,
+		Context: ctx,
+	}
+}
@@ -0,0 +1,516 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultCallTimeout is the default time limit of a single call made
+	// against a single endpoint.
+	defaultCallTimeout = 30 * time.Second
+	// baseUnhealthyPeriod is the time an endpoint is considered unhealthy
+	// after its first failed call. It doubles with each consecutive failure
+	// up to maxUnhealthyPeriod.
+	baseUnhealthyPeriod = 15 * time.Second
+	maxUnhealthyPeriod  = 10 * time.Minute
+)
+
+// Endpoint is an Ethereum JSON-RPC endpoint. Endpoints with higher priority
+// are tried first.
+type Endpoint struct {
+	URL      string
+	Priority int
+}
+
+// EndpointStats summarizes calls made against a single endpoint.
+type EndpointStats struct {
+	// URL without path and credentials, safe to be logged
+	URL      string
+	Priority int
+	Served   uint64
+	Failed   uint64
+	Healthy  bool
+}
+
+type endpoint struct {
+	Endpoint
+	client *ethclient.Client
+
+	served              uint64
+	failed              uint64
+	consecutiveFailures uint
+	unhealthyUntil      time.Time
+}
+
+func (e *endpoint) isHealthy(now time.Time) bool {
+	return !now.Before(e.unhealthyUntil)
+}
+
+// FailoverClient is an Ethereum client backed by multiple endpoints. Each
+// call is made against the healthy endpoint with the highest priority and
+// retried against the next endpoints if it fails or times out. All calls
+// made by the client are reads, hence safe to be retried. Endpoints which
+// failed are skipped for a period growing with each consecutive failure
+// unless no healthy endpoint is left.
+type FailoverClient struct {
+	endpoints   []*endpoint
+	callTimeout time.Duration
+
+	mutex sync.Mutex
+}
+
+// NewFailoverClient connects to the given endpoints. If the chain ID is set,
+// endpoints not serving that chain, or not answering at all, are dropped so
+// calls never fail over to another chain. The default call timeout is used
+// if the given one is zero.
+func NewFailoverClient(
+	ctx context.Context,
+	endpoints []Endpoint,
+	callTimeout time.Duration,
+	chainID uint64,
+) (*FailoverClient, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no Ethereum endpoints configured")
+	}
+
+	if callTimeout == 0 {
+		callTimeout = defaultCallTimeout
+	}
+
+	connected := make([]*endpoint, 0)
+	for _, e := range endpoints {
+		client, err := ethclient.Dial(e.URL)
+		if err != nil {
+			logger.Warnf(
+				"could not connect to endpoint [%v]: [%v]",
+				redactURL(e.URL),
+				err,
+			)
+			continue
+		}
+
+		if err := verifyChainID(ctx, client, callTimeout, chainID); err != nil {
+			logger.Warnf(
+				"skipping endpoint [%v]: [%v]",
+				redactURL(e.URL),
+				err,
+			)
+			client.Close()
+			continue
+		}
+
+		connected = append(connected, &endpoint{Endpoint: e, client: client})
+	}
+
+	if len(connected) == 0 {
+		return nil, fmt.Errorf(
+			"could not connect to any Ethereum endpoint serving the configured chain",
+		)
+	}
+
+	sort.SliceStable(connected, func(i, j int) bool {
+		return connected[i].Priority > connected[j].Priority
+	})
+
+	return &FailoverClient{
+		endpoints:   connected,
+		callTimeout: callTimeout,
+	}, nil
+}
+
+func (fc *FailoverClient) CodeAt(
+	ctx context.Context,
+	contract common.Address,
+	blockNumber *big.Int,
+) ([]byte, error) {
+	var code []byte
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		code, err = client.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (fc *FailoverClient) CallContract(
+	ctx context.Context,
+	call ethereum.CallMsg,
+	blockNumber *big.Int,
+) ([]byte, error) {
+	var result []byte
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		result, err = client.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return result, err
+}
+
+func (fc *FailoverClient) FilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+) ([]types.Log, error) {
+	var logs []types.Log
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		logs, err = client.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+// SubscribeFilterLogs subscribes using the endpoint with the highest
+// priority. Subscriptions are long-lived so they are not retried.
+func (fc *FailoverClient) SubscribeFilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+	ch chan<- types.Log,
+) (ethereum.Subscription, error) {
+	return fc.endpoints[0].client.SubscribeFilterLogs(ctx, query, ch)
+}
+
+func (fc *FailoverClient) HeaderByNumber(
+	ctx context.Context,
+	number *big.Int,
+) (*types.Header, error) {
+	var header *types.Header
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		header, err = client.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (fc *FailoverClient) BalanceAt(
+	ctx context.Context,
+	account common.Address,
+	blockNumber *big.Int,
+) (*big.Int, error) {
+	var balance *big.Int
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		balance, err = client.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (fc *FailoverClient) TransactionByHash(
+	ctx context.Context,
+	hash common.Hash,
+) (*types.Transaction, bool, error) {
+	var tx *types.Transaction
+	var isPending bool
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		tx, isPending, err = client.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (fc *FailoverClient) TransactionReceipt(
+	ctx context.Context,
+	hash common.Hash,
+) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		receipt, err = client.TransactionReceipt(ctx, hash)
+		return err
+	})
+	return receipt, err
+}
+
+func (fc *FailoverClient) TransactionSender(
+	ctx context.Context,
+	tx *types.Transaction,
+	block common.Hash,
+	index uint,
+) (common.Address, error) {
+	var sender common.Address
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		sender, err = client.TransactionSender(ctx, tx, block, index)
+		return err
+	})
+	return sender, err
+}
+
+func (fc *FailoverClient) PendingNonceAt(
+	ctx context.Context,
+	account common.Address,
+) (uint64, error) {
+	var nonce uint64
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		nonce, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (fc *FailoverClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	var gasPrice *big.Int
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		gasPrice, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return gasPrice, err
+}
+
+func (fc *FailoverClient) EstimateGas(
+	ctx context.Context,
+	msg ethereum.CallMsg,
+) (uint64, error) {
+	var gas uint64
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		gas, err = client.EstimateGas(ctx, msg)
+		return err
+	})
+	return gas, err
+}
+
+// SendTransaction sends the signed transaction. A transaction already known
+// to the node counts as sent, so does a failed send after which the node
+// serves the transaction, e.g. when the node accepted it but the response
+// timed out. Only otherwise the transaction is sent to the next endpoint,
+// which is safe as the transaction hash and nonce stay the same.
+func (fc *FailoverClient) SendTransaction(
+	ctx context.Context,
+	tx *types.Transaction,
+) error {
+	return fc.call(ctx, func(callCtx context.Context, client *ethclient.Client) error {
+		err := client.SendTransaction(callCtx, tx)
+		if err == nil || isAlreadyKnownError(err) {
+			return nil
+		}
+
+		// the call context may have already expired
+		lookupCtx, cancel := context.WithTimeout(ctx, fc.callTimeout)
+		defer cancel()
+
+		if _, _, lookupErr := client.TransactionByHash(
+			lookupCtx,
+			tx.Hash(),
+		); lookupErr == nil {
+			return nil
+		}
+
+		return err
+	})
+}
+
+func (fc *FailoverClient) ChainID(ctx context.Context) (*big.Int, error) {
+	var chainID *big.Int
+	err := fc.call(ctx, func(ctx context.Context, client *ethclient.Client) error {
+		var err error
+		chainID, err = client.ChainID(ctx)
+		return err
+	})
+	return chainID, err
+}
+
+// Stats returns the summary of calls made against each endpoint, ordered
+// by priority.
+func (fc *FailoverClient) Stats() []*EndpointStats {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	now := time.Now()
+
+	stats := make([]*EndpointStats, len(fc.endpoints))
+	for i, e := range fc.endpoints {
+		stats[i] = &EndpointStats{
+			URL:      redactURL(e.URL),
+			Priority: e.Priority,
+			Served:   e.served,
+			Failed:   e.failed,
+			Healthy:  e.isHealthy(now),
+		}
+	}
+
+	return stats
+}
+
+// call executes the given call against subsequent endpoints until one of
+// them succeeds. Errors returned by the node itself, like a reverted
+// contract call, are returned immediately as other endpoints would return
+// the same.
+func (fc *FailoverClient) call(
+	ctx context.Context,
+	call func(ctx context.Context, client *ethclient.Client) error,
+) error {
+	var lastErr error
+
+	for _, e := range fc.orderedEndpoints() {
+		callCtx, cancel := context.WithTimeout(ctx, fc.callTimeout)
+		err := call(callCtx, e.client)
+		cancel()
+
+		if err == nil || err == ethereum.NotFound || isRevertError(err) {
+			fc.recordSuccess(e)
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		fc.recordFailure(e, err)
+		lastErr = err
+	}
+
+	return fmt.Errorf("all Ethereum endpoints failed; last error: [%v]", lastErr)
+}
+
+// orderedEndpoints returns healthy endpoints ordered by priority followed
+// by unhealthy ones, which are tried as a last resort.
+func (fc *FailoverClient) orderedEndpoints() []*endpoint {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	now := time.Now()
+
+	healthy := make([]*endpoint, 0, len(fc.endpoints))
+	unhealthy := make([]*endpoint, 0)
+	for _, e := range fc.endpoints {
+		if e.isHealthy(now) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (fc *FailoverClient) recordSuccess(e *endpoint) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	e.served++
+	e.consecutiveFailures = 0
+	e.unhealthyUntil = time.Time{}
+}
+
+func (fc *FailoverClient) recordFailure(e *endpoint, err error) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	e.failed++
+	e.consecutiveFailures++
+
+	unhealthyPeriod := maxUnhealthyPeriod
+	if e.consecutiveFailures < 16 {
+		unhealthyPeriod = baseUnhealthyPeriod << (e.consecutiveFailures - 1)
+		if unhealthyPeriod > maxUnhealthyPeriod {
+			unhealthyPeriod = maxUnhealthyPeriod
+		}
+	}
+	e.unhealthyUntil = time.Now().Add(unhealthyPeriod)
+
+	logger.Warnf(
+		"call to endpoint [%v] failed, marking it unhealthy for [%v]: [%v]",
+		redactURL(e.URL),
+		unhealthyPeriod,
+		err,
+	)
+}
+
+// isRevertError checks whether the error was returned by the node because
+// the call reverted. Such a call reverts on every endpoint so there is no
+// point in retrying it. Other node errors, like a missing trie node on
+// a non-archive node, an exceeded block range or a rate limit, are specific
+// to the endpoint and the call should be retried against the next one.
+func isRevertError(err error) bool {
+	rpcErr, ok := err.(rpc.Error)
+	if !ok {
+		return false
+	}
+
+	// Code 3 is returned along with the revert reason data.
+	if rpcErr.ErrorCode() == 3 {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "execution reverted") ||
+		strings.Contains(message, "invalid opcode")
+}
+
+// verifyChainID makes sure the endpoint serves the expected chain. The check
+// is skipped if the expected chain ID is not set.
+func verifyChainID(
+	ctx context.Context,
+	client *ethclient.Client,
+	callTimeout time.Duration,
+	expectedChainID uint64,
+) error {
+	if expectedChainID == 0 {
+		return nil
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	chainID, err := client.ChainID(callCtx)
+	if err != nil {
+		return fmt.Errorf("could not get chain ID: [%v]", err)
+	}
+
+	if !chainID.IsUint64() || chainID.Uint64() != expectedChainID {
+		return fmt.Errorf(
+			"endpoint serves chain [%v] but chain [%v] is configured",
+			chainID,
+			expectedChainID,
+		)
+	}
+
+	return nil
+}
+
+// isAlreadyKnownError checks whether the node rejected a transaction because
+// it already knows it. Messages differ between node implementations.
+func isAlreadyKnownError(err error) bool {
+	message := strings.ToLower(err.Error())
+
+	for _, known := range []string{
+		"already known",
+		"known transaction",
+		"already imported",
+	} {
+		if strings.Contains(message, known) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactURL strips the path and credentials from the endpoint URL as
+// providers often put API keys there.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || len(parsed.Host) == 0 {
+		return "<invalid URL>"
+	}
+
+	return parsed.Scheme + "://" + parsed.Host
+}
@@ -0,0 +1,629 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultMaxChunkSize is the default maximum number of blocks fetched in a
+// single logs query.
+const defaultMaxChunkSize = 10000
+
+// logSource is the remote source of logs synced by the indexer.
+type logSource interface {
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// logStore is the local copy of logs emitted by the indexed contracts kept
+// in a JSON file between runs.
+type logStore struct {
+	Addresses       []common.Address
+	StartBlock      uint64
+	LastSyncedBlock uint64
+	// Synced tells whether any block was synced; LastSyncedBlock is
+	// meaningless otherwise, as block 0 may be the last synced one
+	Synced bool
+	Logs   []types.Log
+	// BlockHashes holds hashes of the last block of each synced chunk and
+	// of all blocks with stored logs. They are compared with the chain to
+	// detect reorganisations of already synced blocks.
+	BlockHashes map[uint64]common.Hash
+}
+
+// LogIndexer pulls logs of the given contracts from the remote source in
+// chunked block ranges and stores them locally. Each sync resumes from the
+// last synced block. The indexer serves log queries from the local store so
+// it can be used instead of the remote source by generated contract
+// filterers. Blocks without the configured number of confirmations are
+// never synced and logs of blocks reorganised since the previous run are
+// rolled back before serving any query.
+type LogIndexer struct {
+	source        logSource
+	storeFile     string
+	maxChunkSize  uint64
+	confirmations uint64
+
+	store            *logStore
+	reorgsRolledBack bool
+}
+
+// NewLogIndexer creates an indexer of logs emitted by the given contracts
+// since the given start block, typically the block the oldest of them was
+// deployed at. Logs synced during previous runs are loaded from the store
+// file unless the file was created for a different set of contracts.
+func NewLogIndexer(
+	source logSource,
+	storeFile string,
+	addresses []common.Address,
+	startBlock uint64,
+	maxChunkSize uint64,
+	confirmations uint64,
+) (*LogIndexer, error) {
+	if maxChunkSize == 0 {
+		maxChunkSize = defaultMaxChunkSize
+	}
+
+	store, err := loadLogStore(storeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if store == nil ||
+		store.StartBlock != startBlock ||
+		!sameAddresses(store.Addresses, addresses) {
+		logger.Infof("creating new log store in [%v]", storeFile)
+
+		store = newLogStore(addresses, startBlock)
+	}
+
+	if store.BlockHashes == nil {
+		store.BlockHashes = make(map[uint64]common.Hash)
+	}
+
+	// stores saved before the synced flag was introduced
+	if !store.Synced &&
+		store.LastSyncedBlock > 0 &&
+		store.LastSyncedBlock >= store.StartBlock {
+		store.Synced = true
+	}
+
+	return &LogIndexer{
+		source:        source,
+		storeFile:     storeFile,
+		maxChunkSize:  maxChunkSize,
+		confirmations: confirmations,
+		store:         store,
+	}, nil
+}
+
+func newLogStore(addresses []common.Address, startBlock uint64) *logStore {
+	return &logStore{
+		Addresses:       addresses,
+		StartBlock:      startBlock,
+		LastSyncedBlock: 0,
+		Synced:          false,
+		Logs:            make([]types.Log, 0),
+		BlockHashes:     make(map[uint64]common.Hash),
+	}
+}
+
+// Sync pulls all logs up to the given block not synced yet and persists
+// them in the store file. Chunks rejected by the remote source, for example
+// because the range or the number of results exceeds the provider limit,
+// are split in halves until they are accepted. Logs of synced blocks which
+// are no longer part of the chain are rolled back and synced again.
+func (li *LogIndexer) Sync(ctx context.Context, toBlock uint64) error {
+	if err := li.rollBackReorgs(ctx); err != nil {
+		return err
+	}
+
+	fromBlock := li.nextBlock()
+	if fromBlock > toBlock {
+		return nil
+	}
+
+	logger.Infof("syncing logs in blocks [%v - %v]", fromBlock, toBlock)
+
+	chunkSize := li.maxChunkSize
+
+	for fromBlock <= toBlock {
+		chunkEnd := fromBlock + chunkSize - 1
+		if chunkEnd > toBlock {
+			chunkEnd = toBlock
+		}
+
+		logs, err := li.source.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(fromBlock),
+			ToBlock:   new(big.Int).SetUint64(chunkEnd),
+			Addresses: li.store.Addresses,
+		})
+		if err != nil {
+			if chunkSize == 1 || ctx.Err() != nil {
+				if saveErr := li.save(); saveErr != nil {
+					logger.Errorf("could not save log store: [%v]", saveErr)
+				}
+
+				return fmt.Errorf(
+					"could not get logs in blocks [%v - %v]: [%v]",
+					fromBlock,
+					chunkEnd,
+					err,
+				)
+			}
+
+			chunkSize = (chunkEnd - fromBlock + 1) / 2
+			logger.Debugf(
+				"could not get logs in blocks [%v - %v], "+
+					"retrying with chunk size [%v]: [%v]",
+				fromBlock,
+				chunkEnd,
+				chunkSize,
+				err,
+			)
+			continue
+		}
+
+		chunkEndHeader, err := li.source.HeaderByNumber(
+			ctx,
+			new(big.Int).SetUint64(chunkEnd),
+		)
+		if err != nil {
+			return fmt.Errorf(
+				"could not get header of block [%v]: [%v]",
+				chunkEnd,
+				err,
+			)
+		}
+
+		li.append(logs, chunkEnd, chunkEndHeader.Hash())
+		fromBlock = chunkEnd + 1
+
+		// the chunk was accepted so try a bigger one next time
+		if chunkSize < li.maxChunkSize {
+			chunkSize *= 2
+			if chunkSize > li.maxChunkSize {
+				chunkSize = li.maxChunkSize
+			}
+		}
+	}
+
+	logger.Infof(
+		"synced logs up to block [%v], [%v] logs stored",
+		li.store.LastSyncedBlock,
+		len(li.store.Logs),
+	)
+
+	return li.save()
+}
+
+// filterLogsInChunks queries logs matching the query in the given block
+// range in chunks of at most the given number of blocks, splitting chunks
+// rejected by the source in halves the same way the indexer sync does. It
+// serves one-off queries of contracts not covered by the indexer.
+func filterLogsInChunks(
+	ctx context.Context,
+	source logSource,
+	query ethereum.FilterQuery,
+	fromBlock uint64,
+	toBlock uint64,
+	maxChunkSize uint64,
+) ([]types.Log, error) {
+	if maxChunkSize == 0 {
+		maxChunkSize = defaultMaxChunkSize
+	}
+
+	logs := make([]types.Log, 0)
+	chunkSize := maxChunkSize
+
+	for fromBlock <= toBlock {
+		chunkEnd := fromBlock + chunkSize - 1
+		if chunkEnd > toBlock {
+			chunkEnd = toBlock
+		}
+
+		chunkQuery := query
+		chunkQuery.FromBlock = new(big.Int).SetUint64(fromBlock)
+		chunkQuery.ToBlock = new(big.Int).SetUint64(chunkEnd)
+
+		chunkLogs, err := source.FilterLogs(ctx, chunkQuery)
+		if err != nil {
+			if chunkSize == 1 || ctx.Err() != nil {
+				return nil, fmt.Errorf(
+					"could not get logs in blocks [%v - %v]: [%v]",
+					fromBlock,
+					chunkEnd,
+					err,
+				)
+			}
+
+			chunkSize = (chunkEnd - fromBlock + 1) / 2
+			continue
+		}
+
+		logs = append(logs, chunkLogs...)
+		fromBlock = chunkEnd + 1
+
+		if chunkSize < maxChunkSize {
+			chunkSize *= 2
+			if chunkSize > maxChunkSize {
+				chunkSize = maxChunkSize
+			}
+		}
+	}
+
+	return logs, nil
+}
+
+// chunkedLogSource serves log queries of generated contract filterers not
+// covered by the indexer in chunks of at most the given number of blocks, so
+// queries over long periods are not rejected by providers limiting the
+// block range or the number of results. Queries without end block are
+// served up to the most recent block.
+type chunkedLogSource struct {
+	logSource
+	maxChunkSize uint64
+}
+
+func newChunkedLogSource(
+	source logSource,
+	maxChunkSize uint64,
+) *chunkedLogSource {
+	if maxChunkSize == 0 {
+		maxChunkSize = defaultMaxChunkSize
+	}
+
+	return &chunkedLogSource{
+		logSource:    source,
+		maxChunkSize: maxChunkSize,
+	}
+}
+
+// FilterLogs returns logs matching the query, queried in chunks.
+func (cls *chunkedLogSource) FilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+) ([]types.Log, error) {
+	if query.BlockHash != nil {
+		return cls.logSource.FilterLogs(ctx, query)
+	}
+
+	fromBlock := uint64(0)
+	if query.FromBlock != nil {
+		fromBlock = query.FromBlock.Uint64()
+	}
+
+	var toBlock uint64
+	if query.ToBlock != nil {
+		toBlock = query.ToBlock.Uint64()
+	} else {
+		header, err := cls.logSource.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		toBlock = header.Number.Uint64()
+	}
+
+	return filterLogsInChunks(
+		ctx,
+		cls.logSource,
+		query,
+		fromBlock,
+		toBlock,
+		cls.maxChunkSize,
+	)
+}
+
+// SubscribeFilterLogs is not supported; only past logs are served.
+func (cls *chunkedLogSource) SubscribeFilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+	ch chan<- types.Log,
+) (ethereum.Subscription, error) {
+	return nil, fmt.Errorf("log subscriptions are not supported")
+}
+
+// LastSyncedBlock returns the most recent block with logs available in the
+// local store.
+func (li *LogIndexer) LastSyncedBlock() uint64 {
+	return li.store.LastSyncedBlock
+}
+
+// FilterLogs returns stored logs matching the query. If the query range
+// ends after the last synced block, logs are synced first. Queries are
+// served up to the most recent confirmed block at most, so blocks which may
+// still be reorganised are never stored. Blocks before the indexer start
+// block have no logs.
+func (li *LogIndexer) FilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+) ([]types.Log, error) {
+	if query.BlockHash != nil {
+		return nil, fmt.Errorf("log queries by block hash are not supported")
+	}
+
+	fromBlock := uint64(0)
+	if query.FromBlock != nil {
+		fromBlock = query.FromBlock.Uint64()
+	}
+
+	// indexed contracts emit nothing before they are deployed
+	if fromBlock < li.store.StartBlock {
+		fromBlock = li.store.StartBlock
+	}
+
+	header, err := li.source.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	toBlock := confirmedBlock(header.Number.Uint64(), li.confirmations)
+	if query.ToBlock != nil && query.ToBlock.Uint64() < toBlock {
+		toBlock = query.ToBlock.Uint64()
+	}
+
+	if err := li.rollBackReorgs(ctx); err != nil {
+		return nil, err
+	}
+
+	if toBlock >= li.nextBlock() {
+		if err := li.Sync(ctx, toBlock); err != nil {
+			return nil, err
+		}
+	}
+
+	logs := li.store.Logs
+	first := sort.Search(len(logs), func(i int) bool {
+		return logs[i].BlockNumber >= fromBlock
+	})
+
+	matching := make([]types.Log, 0)
+	for _, log := range logs[first:] {
+		if log.BlockNumber > toBlock {
+			break
+		}
+
+		if matchesQuery(log, query) {
+			matching = append(matching, log)
+		}
+	}
+
+	return matching, nil
+}
+
+// SubscribeFilterLogs is not supported by the indexer; it serves only past
+// logs.
+func (li *LogIndexer) SubscribeFilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+	ch chan<- types.Log,
+) (ethereum.Subscription, error) {
+	return nil, fmt.Errorf("log subscriptions are not supported by the indexer")
+}
+
+func (li *LogIndexer) nextBlock() uint64 {
+	if !li.store.Synced {
+		return li.store.StartBlock
+	}
+
+	return li.store.LastSyncedBlock + 1
+}
+
+// rollBackReorgs compares stored block hashes with the chain, starting from
+// the most recent one, and drops everything synced after the most recent
+// block still being part of the chain. The check is done once per indexer
+// as the indexer never syncs blocks without enough confirmations.
+func (li *LogIndexer) rollBackReorgs(ctx context.Context) error {
+	if li.reorgsRolledBack || !li.store.Synced {
+		return nil
+	}
+
+	blocks := make([]uint64, 0, len(li.store.BlockHashes))
+	for block := range li.store.BlockHashes {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] > blocks[j] })
+
+	validBlock := uint64(0)
+	found := false
+	for _, block := range blocks {
+		header, err := li.source.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+		if err != nil {
+			return fmt.Errorf(
+				"could not get header of block [%v]: [%v]",
+				block,
+				err,
+			)
+		}
+
+		if header.Hash() == li.store.BlockHashes[block] {
+			validBlock = block
+			found = true
+			break
+		}
+	}
+
+	li.reorgsRolledBack = true
+
+	if !found {
+		logger.Warnf(
+			"all synced blocks were reorganised; "+
+				"rolling back logs synced up to block [%v]",
+			li.store.LastSyncedBlock,
+		)
+
+		li.store = newLogStore(li.store.Addresses, li.store.StartBlock)
+
+		return li.save()
+	}
+
+	if validBlock == li.store.LastSyncedBlock {
+		return nil
+	}
+
+	logger.Warnf(
+		"synced blocks after block [%v] were reorganised; "+
+			"rolling back logs synced up to block [%v]",
+		validBlock,
+		li.store.LastSyncedBlock,
+	)
+
+	li.rollBack(validBlock)
+
+	return li.save()
+}
+
+// rollBack drops all logs and block hashes stored for blocks after the
+// given one.
+func (li *LogIndexer) rollBack(lastValidBlock uint64) {
+	logs := li.store.Logs
+	first := sort.Search(len(logs), func(i int) bool {
+		return logs[i].BlockNumber > lastValidBlock
+	})
+	li.store.Logs = logs[:first]
+
+	for block := range li.store.BlockHashes {
+		if block > lastValidBlock {
+			delete(li.store.BlockHashes, block)
+		}
+	}
+
+	li.store.LastSyncedBlock = lastValidBlock
+}
+
+func (li *LogIndexer) append(
+	logs []types.Log,
+	lastSyncedBlock uint64,
+	lastSyncedBlockHash common.Hash,
+) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	for _, log := range logs {
+		li.store.BlockHashes[log.BlockNumber] = log.BlockHash
+	}
+
+	li.store.Logs = append(li.store.Logs, logs...)
+	li.store.BlockHashes[lastSyncedBlock] = lastSyncedBlockHash
+	li.store.LastSyncedBlock = lastSyncedBlock
+	li.store.Synced = true
+}
+
+func (li *LogIndexer) save() error {
+	storeBytes, err := json.Marshal(li.store)
+	if err != nil {
+		return err
+	}
+
+	directory := filepath.Dir(li.storeFile)
+	if err := os.MkdirAll(directory, 0777); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so the store is never left truncated
+	tempFile, err := ioutil.TempFile(directory, filepath.Base(li.storeFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(storeBytes); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), li.storeFile)
+}
+
+func loadLogStore(storeFile string) (*logStore, error) {
+	storeBytes, err := ioutil.ReadFile(storeFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	store := &logStore{}
+	if err := json.Unmarshal(storeBytes, store); err != nil {
+		return nil, fmt.Errorf(
+			"could not decode log store [%v]: [%v]",
+			storeFile,
+			err,
+		)
+	}
+
+	return store, nil
+}
+
+func matchesQuery(log types.Log, query ethereum.FilterQuery) bool {
+	if len(query.Addresses) > 0 {
+		addressMatches := false
+		for _, address := range query.Addresses {
+			if log.Address == address {
+				addressMatches = true
+				break
+			}
+		}
+
+		if !addressMatches {
+			return false
+		}
+	}
+
+	if len(query.Topics) > len(log.Topics) {
+		return false
+	}
+
+	for i, alternatives := range query.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+
+		topicMatches := false
+		for _, topic := range alternatives {
+			if log.Topics[i] == topic {
+				topicMatches = true
+				break
+			}
+		}
+
+		if !topicMatches {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameAddresses(a []common.Address, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,552 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	indexedAddress = common.HexToAddress("0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+	topicA         = common.HexToHash("0x0A")
+	topicB         = common.HexToHash("0x0B")
+)
+
+// rangeLimitedSource serves one log per block and rejects queries covering
+// more blocks than the limit. If the fork is set, blocks starting from the
+// fork block belong to the given fork of the chain.
+type rangeLimitedSource struct {
+	rangeLimit uint64
+	headBlock  uint64
+	forkBlock  uint64
+	fork       byte
+
+	queriedRanges [][2]uint64
+}
+
+func (rls *rangeLimitedSource) header(block uint64) *types.Header {
+	header := &types.Header{Number: new(big.Int).SetUint64(block)}
+	if rls.fork != 0 && block >= rls.forkBlock {
+		header.Extra = []byte{rls.fork}
+	}
+
+	return header
+}
+
+func (rls *rangeLimitedSource) FilterLogs(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+) ([]types.Log, error) {
+	fromBlock := query.FromBlock.Uint64()
+	toBlock := query.ToBlock.Uint64()
+
+	rls.queriedRanges = append(rls.queriedRanges, [2]uint64{fromBlock, toBlock})
+
+	if toBlock-fromBlock+1 > rls.rangeLimit {
+		return nil, fmt.Errorf("query returned more than 10000 results")
+	}
+
+	logs := make([]types.Log, 0)
+	for block := fromBlock; block <= toBlock; block++ {
+		topic := topicA
+		if block%2 == 0 {
+			topic = topicB
+		}
+
+		logs = append(logs, types.Log{
+			Address:     indexedAddress,
+			Topics:      []common.Hash{topic},
+			Data:        []byte{},
+			BlockNumber: block,
+			BlockHash:   rls.header(block).Hash(),
+		})
+	}
+
+	return logs, nil
+}
+
+func (rls *rangeLimitedSource) HeaderByNumber(
+	ctx context.Context,
+	number *big.Int,
+) (*types.Header, error) {
+	if number == nil {
+		return rls.header(rls.headBlock), nil
+	}
+
+	return rls.header(number.Uint64()), nil
+}
+
+func newTestIndexer(
+	t *testing.T,
+	source logSource,
+	storeFile string,
+) *LogIndexer {
+	indexer, err := NewLogIndexer(
+		source,
+		storeFile,
+		[]common.Address{indexedAddress},
+		100,
+		16,
+		0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return indexer
+}
+
+func TestLogIndexerSyncSplitsRejectedChunks(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	source := &rangeLimitedSource{rangeLimit: 5}
+	indexer := newTestIndexer(t, source, filepath.Join(directory, "logs.json"))
+
+	if err := indexer.Sync(context.Background(), 149); err != nil {
+		t.Fatal(err)
+	}
+
+	if indexer.LastSyncedBlock() != 149 {
+		t.Errorf(
+			"unexpected last synced block\nexpected: [149]\nactual:   [%v]",
+			indexer.LastSyncedBlock(),
+		)
+	}
+
+	logs := indexer.store.Logs
+	if len(logs) != 50 {
+		t.Fatalf(
+			"unexpected number of logs\nexpected: [50]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+
+	for i, log := range logs {
+		if log.BlockNumber != uint64(100+i) {
+			t.Errorf(
+				"unexpected block of log [%v]\nexpected: [%v]\nactual:   [%v]",
+				i,
+				100+i,
+				log.BlockNumber,
+			)
+		}
+	}
+}
+
+func TestLogIndexerResumesFromStore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	storeFile := filepath.Join(directory, "logs.json")
+
+	source := &rangeLimitedSource{rangeLimit: 100}
+	if err := newTestIndexer(t, source, storeFile).Sync(
+		context.Background(),
+		120,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	source = &rangeLimitedSource{rangeLimit: 100}
+	indexer := newTestIndexer(t, source, storeFile)
+
+	if err := indexer.Sync(context.Background(), 130); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(source.queriedRanges) != 1 || source.queriedRanges[0][0] != 121 {
+		t.Errorf("expected single query starting at block [121]\nactual: [%v]",
+			source.queriedRanges,
+		)
+	}
+
+	if len(indexer.store.Logs) != 31 {
+		t.Errorf(
+			"unexpected number of logs\nexpected: [31]\nactual:   [%v]",
+			len(indexer.store.Logs),
+		)
+	}
+}
+
+func TestLogIndexerFilterLogs(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	source := &rangeLimitedSource{rangeLimit: 100, headBlock: 200}
+	indexer := newTestIndexer(t, source, filepath.Join(directory, "logs.json"))
+
+	logs, err := indexer.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(110),
+		ToBlock:   big.NewInt(119),
+		Addresses: []common.Address{indexedAddress},
+		Topics:    [][]common.Hash{{topicA}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 5 {
+		t.Fatalf(
+			"unexpected number of logs\nexpected: [5]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+
+	for _, log := range logs {
+		if log.Topics[0] != topicA || log.BlockNumber < 110 || log.BlockNumber > 119 {
+			t.Errorf("unexpected log: [%+v]", log)
+		}
+	}
+
+	// query without end block syncs up to the current block
+	if _, err := indexer.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(100),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if indexer.LastSyncedBlock() != 200 {
+		t.Errorf(
+			"unexpected last synced block\nexpected: [200]\nactual:   [%v]",
+			indexer.LastSyncedBlock(),
+		)
+	}
+}
+
+func TestLogIndexerFilterLogsBeforeStartBlock(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	var tests = map[string]struct {
+		fromBlock         int64
+		toBlock           int64
+		expectedLogsCount int
+	}{
+		"range overlapping the start block": {
+			fromBlock:         0,
+			toBlock:           109,
+			expectedLogsCount: 10,
+		},
+		"range ending before the start block": {
+			fromBlock:         0,
+			toBlock:           99,
+			expectedLogsCount: 0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			source := &rangeLimitedSource{rangeLimit: 100, headBlock: 200}
+			indexer := newTestIndexer(
+				t,
+				source,
+				filepath.Join(directory, testName+".json"),
+			)
+
+			logs, err := indexer.FilterLogs(
+				context.Background(),
+				ethereum.FilterQuery{
+					FromBlock: big.NewInt(test.fromBlock),
+					ToBlock:   big.NewInt(test.toBlock),
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(logs) != test.expectedLogsCount {
+				t.Errorf(
+					"unexpected number of logs\nexpected: [%v]\nactual:   [%v]",
+					test.expectedLogsCount,
+					len(logs),
+				)
+			}
+
+			for _, queried := range source.queriedRanges {
+				if queried[0] < 100 {
+					t.Errorf("unexpected query before the start block: [%v]", queried)
+				}
+			}
+		})
+	}
+}
+
+func TestLogIndexerRollsBackReorganisedBlocks(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	storeFile := filepath.Join(directory, "logs.json")
+
+	source := &rangeLimitedSource{rangeLimit: 100}
+	if err := newTestIndexer(t, source, storeFile).Sync(
+		context.Background(),
+		150,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// blocks from 140 on were replaced by a competing fork
+	source = &rangeLimitedSource{rangeLimit: 100, forkBlock: 140, fork: 1}
+	indexer := newTestIndexer(t, source, storeFile)
+
+	if err := indexer.Sync(context.Background(), 150); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(source.queriedRanges) != 1 || source.queriedRanges[0][0] != 140 {
+		t.Errorf("expected single query starting at block [140]\nactual: [%v]",
+			source.queriedRanges,
+		)
+	}
+
+	logs := indexer.store.Logs
+	if len(logs) != 51 {
+		t.Fatalf(
+			"unexpected number of logs\nexpected: [51]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+
+	for i, log := range logs {
+		expectedHash := source.header(log.BlockNumber).Hash()
+		if log.BlockNumber != uint64(100+i) || log.BlockHash != expectedHash {
+			t.Errorf("unexpected log [%v]: [%+v]", i, log)
+		}
+	}
+}
+
+func TestLogIndexerFilterLogsSkipsUnconfirmedBlocks(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	source := &rangeLimitedSource{rangeLimit: 100, headBlock: 200}
+	indexer, err := NewLogIndexer(
+		source,
+		filepath.Join(directory, "logs.json"),
+		[]common.Address{indexedAddress},
+		100,
+		16,
+		12,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := indexer.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(100),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if indexer.LastSyncedBlock() != 188 {
+		t.Errorf(
+			"unexpected last synced block\nexpected: [188]\nactual:   [%v]",
+			indexer.LastSyncedBlock(),
+		)
+	}
+}
+
+func TestLogIndexerFilterLogsClampsEndBlock(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	source := &rangeLimitedSource{rangeLimit: 100, headBlock: 200}
+	indexer, err := NewLogIndexer(
+		source,
+		filepath.Join(directory, "logs.json"),
+		[]common.Address{indexedAddress},
+		100,
+		16,
+		12,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := indexer.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: big.NewInt(180),
+		ToBlock:   big.NewInt(250),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if indexer.LastSyncedBlock() != 188 {
+		t.Errorf(
+			"unexpected last synced block\nexpected: [188]\nactual:   [%v]",
+			indexer.LastSyncedBlock(),
+		)
+	}
+
+	if len(logs) != 9 {
+		t.Errorf(
+			"unexpected number of logs\nexpected: [9]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+}
+
+func TestLogIndexerResyncsBlockZero(t *testing.T) {
+	directory, err := ioutil.TempDir("", "indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	storeFile := filepath.Join(directory, "logs.json")
+
+	newIndexer := func(source logSource) *LogIndexer {
+		indexer, err := NewLogIndexer(
+			source,
+			storeFile,
+			[]common.Address{indexedAddress},
+			0,
+			16,
+			0,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return indexer
+	}
+
+	source := &rangeLimitedSource{rangeLimit: 100}
+	if err := newIndexer(source).Sync(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(source.queriedRanges) != 1 || source.queriedRanges[0][0] != 0 {
+		t.Errorf(
+			"expected single query starting at block [0]\nactual: [%v]",
+			source.queriedRanges,
+		)
+	}
+
+	// all blocks, including block 0, were replaced by a competing fork
+	source = &rangeLimitedSource{rangeLimit: 100, forkBlock: 0, fork: 1}
+	indexer := newIndexer(source)
+
+	if err := indexer.Sync(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(source.queriedRanges) != 1 || source.queriedRanges[0][0] != 0 {
+		t.Errorf(
+			"expected single query starting at block [0]\nactual: [%v]",
+			source.queriedRanges,
+		)
+	}
+
+	logs := indexer.store.Logs
+	if len(logs) != 11 {
+		t.Fatalf(
+			"unexpected number of logs\nexpected: [11]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+
+	for i, log := range logs {
+		expectedHash := source.header(log.BlockNumber).Hash()
+		if log.BlockNumber != uint64(i) || log.BlockHash != expectedHash {
+			t.Errorf("unexpected log [%v]: [%+v]", i, log)
+		}
+	}
+}
+
+func TestChunkedLogSource(t *testing.T) {
+	source := &rangeLimitedSource{rangeLimit: 100, headBlock: 149}
+
+	logs, err := newChunkedLogSource(source, 16).FilterLogs(
+		context.Background(),
+		ethereum.FilterQuery{
+			FromBlock: big.NewInt(100),
+			Addresses: []common.Address{indexedAddress},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 50 {
+		t.Errorf(
+			"unexpected number of logs\nexpected: [50]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+
+	for _, queriedRange := range source.queriedRanges {
+		if queriedRange[1]-queriedRange[0]+1 > 16 {
+			t.Errorf("unexpected query of blocks [%v]", queriedRange)
+		}
+	}
+}
+
+func TestFilterLogsInChunks(t *testing.T) {
+	source := &rangeLimitedSource{rangeLimit: 5}
+
+	logs, err := filterLogsInChunks(
+		context.Background(),
+		source,
+		ethereum.FilterQuery{Addresses: []common.Address{indexedAddress}},
+		100,
+		149,
+		16,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 50 {
+		t.Errorf(
+			"unexpected number of logs\nexpected: [50]\nactual:   [%v]",
+			len(logs),
+		)
+	}
+
+	for _, queriedRange := range source.queriedRanges {
+		if queriedRange[1]-queriedRange[0]+1 > 16 {
+			t.Errorf("unexpected query of blocks [%v]", queriedRange)
+		}
+	}
+
+	for i, log := range logs {
+		if log.BlockNumber != uint64(100+i) {
+			t.Fatalf(
+				"unexpected log block\nexpected: [%v]\nactual:   [%v]",
+				100+i,
+				log.BlockNumber,
+			)
+		}
+	}
+}
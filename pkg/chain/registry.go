@@ -0,0 +1,205 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+
+	coreabi "github.com/boar-network/keep-billings/pkg/chain/gen/core/abi"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// operatorContractApprovedTopic is the topic of the
+// OperatorContractApproved(address) event emitted by the KEEP Registry
+// contract. The approved contract address is not indexed so it's carried
+// in the log data.
+var operatorContractApprovedTopic = crypto.Keccak256Hash(
+	[]byte("OperatorContractApproved(address)"),
+)
+
+// operatorContract is a beacon operator contract. Groups created by an
+// operator contract stay there even when a newer contract is approved so
+// reports have to cover all of them.
+type operatorContract struct {
+	address common.Address
+	// block the contract was approved at in the registry; zero for the
+	// configured contract not found in the registry
+	approvalBlock uint64
+	// number of blocks a group stays active after its registration; read
+	// from the contract on first use
+	groupActiveTime uint64
+
+	caller   *coreabi.KeepRandomBeaconOperatorCaller
+	filterer *coreabi.KeepRandomBeaconOperatorFilterer
+}
+
+func newOperatorContract(
+	address common.Address,
+	caller bind.ContractCaller,
+	filterer bind.ContractFilterer,
+) (*operatorContract, error) {
+	operatorCaller, err := coreabi.NewKeepRandomBeaconOperatorCaller(
+		address,
+		caller,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	operatorFilterer, err := coreabi.NewKeepRandomBeaconOperatorFilterer(
+		address,
+		filterer,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &operatorContract{
+		address:  address,
+		caller:   operatorCaller,
+		filterer: operatorFilterer,
+	}, nil
+}
+
+// DiscoverOperatorContracts looks up all operator contracts ever approved
+// in the KEEP Registry up to the most recent confirmed block. The Registry
+// approves operator contracts of all KEEP applications so only contracts
+// serving beacon groups are taken. Afterwards, the client serves data of
+// all discovered contracts, ordered by approval, and of the configured
+// operator contract. Approvals are looked up since the given start block,
+// typically the Registry deployment block, in chunks. It has to be called
+// before enabling the log indexer.
+func (ec *EthereumClient) DiscoverOperatorContracts(
+	ctx context.Context,
+	registryAddress string,
+	startBlock uint64,
+) error {
+	registry := common.HexToAddress(registryAddress)
+
+	if err := verifyContractCode(ctx, ec.client, registry); err != nil {
+		return err
+	}
+
+	confirmedBlock, err := ec.CurrentBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get current block: [%v]", err)
+	}
+
+	logs, err := filterLogsInChunks(
+		ctx,
+		ec.client,
+		ethereum.FilterQuery{
+			Addresses: []common.Address{registry},
+			Topics:    [][]common.Hash{{operatorContractApprovedTopic}},
+		},
+		startBlock,
+		confirmedBlock,
+		ec.logs.maxChunkSize,
+	)
+	if err != nil {
+		return fmt.Errorf("could not get approved operator contracts: [%v]", err)
+	}
+
+	operatorContracts := make([]*operatorContract, 0)
+	isKnown := func(address common.Address) bool {
+		for _, operatorContract := range operatorContracts {
+			if operatorContract.address == address {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, log := range logs {
+		if len(log.Data) < common.HashLength {
+			return fmt.Errorf(
+				"malformed operator contract approval in transaction [%v]",
+				log.TxHash.Hex(),
+			)
+		}
+
+		address := common.BytesToAddress(log.Data[:common.HashLength])
+		if isKnown(address) {
+			continue
+		}
+
+		operatorContract, err := newOperatorContract(address, ec.client, ec.logs)
+		if err != nil {
+			return err
+		}
+
+		// contracts of other applications, like ECDSA keep factories,
+		// don't expose beacon groups
+		if _, err := operatorContract.caller.GetNumberOfCreatedGroups(
+			ec.callOpts(ctx),
+		); err != nil {
+			logger.Debugf(
+				"skipping approved operator contract [%v] "+
+					"not serving beacon groups: [%v]",
+				address.Hex(),
+				err,
+			)
+			continue
+		}
+
+		logger.Infof(
+			"discovered beacon operator contract [%v] approved at block [%v]",
+			address.Hex(),
+			log.BlockNumber,
+		)
+
+		operatorContract.approvalBlock = log.BlockNumber
+
+		operatorContracts = append(operatorContracts, operatorContract)
+	}
+
+	if !isKnown(ec.operatorContractAddress) {
+		logger.Warnf(
+			"configured operator contract [%v] is not approved in registry [%v]",
+			ec.operatorContractAddress.Hex(),
+			registry.Hex(),
+		)
+
+		configured, err := ec.getOperatorContract(ec.operatorContractAddress.Hex())
+		if err != nil {
+			return err
+		}
+
+		operatorContracts = append(operatorContracts, configured)
+	}
+
+	ec.operatorContracts = operatorContracts
+
+	return nil
+}
+
+// OperatorContracts returns addresses of all known beacon operator
+// contracts, ordered from the oldest one. If the client is pinned to
+// a block, contracts approved after that block are omitted.
+func (ec *EthereumClient) OperatorContracts() []string {
+	addresses := make([]string, 0, len(ec.operatorContracts))
+	for _, operatorContract := range ec.operatorContracts {
+		if ec.pinnedBlock != nil &&
+			operatorContract.approvalBlock > ec.pinnedBlock.Uint64() {
+			continue
+		}
+
+		addresses = append(addresses, operatorContract.address.Hex())
+	}
+
+	return addresses
+}
+
+func (ec *EthereumClient) getOperatorContract(
+	address string,
+) (*operatorContract, error) {
+	for _, operatorContract := range ec.operatorContracts {
+		if operatorContract.address == common.HexToAddress(address) {
+			return operatorContract, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operator contract [%v]", address)
+}
@@ -0,0 +1,305 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	registryAddress   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	beaconContractA   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	beaconContractB   = common.HexToAddress("0x3333333333333333333333333333333333333333")
+	ecdsaFactory      = common.HexToAddress("0x4444444444444444444444444444444444444444")
+	configuredAddress = beaconContractB
+)
+
+// approvalLog is the registry log approving the operator contract at the
+// given block.
+func approvalLog(address common.Address, block uint64) types.Log {
+	return types.Log{
+		Address:     registryAddress,
+		Topics:      []common.Hash{operatorContractApprovedTopic},
+		Data:        address.Hash().Bytes(),
+		BlockNumber: block,
+		TxHash:      common.BigToHash(new(big.Int).SetUint64(block)),
+	}
+}
+
+// newRegistryNode starts a JSON-RPC server serving the given registry logs.
+// Calls to beacon contracts return a single created group while calls to
+// other contracts revert.
+func newRegistryNode(
+	headBlock uint64,
+	logs []types.Log,
+	beaconContracts []common.Address,
+) *httptest.Server {
+	isBeacon := func(address common.Address) bool {
+		for _, beaconContract := range beaconContracts {
+			if beaconContract == address {
+				return true
+			}
+		}
+		return false
+	}
+
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				ID     json.RawMessage   `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			response := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      request.ID,
+			}
+
+			switch request.Method {
+			case "eth_getCode":
+				response["result"] = "0x6000"
+			case "eth_getBlockByNumber":
+				response["result"] = &types.Header{
+					Number:     new(big.Int).SetUint64(headBlock),
+					Difficulty: big.NewInt(0),
+				}
+			case "eth_getLogs":
+				var query struct {
+					FromBlock string `json:"fromBlock"`
+					ToBlock   string `json:"toBlock"`
+				}
+				json.Unmarshal(request.Params[0], &query)
+				fromBlock, _ := hexutil.DecodeUint64(query.FromBlock)
+				toBlock, _ := hexutil.DecodeUint64(query.ToBlock)
+
+				matching := make([]types.Log, 0)
+				for _, log := range logs {
+					if log.BlockNumber >= fromBlock && log.BlockNumber <= toBlock {
+						matching = append(matching, log)
+					}
+				}
+				response["result"] = matching
+			case "eth_call":
+				var call struct {
+					To common.Address `json:"to"`
+				}
+				json.Unmarshal(request.Params[0], &call)
+
+				if isBeacon(call.To) {
+					response["result"] = hexutil.Bytes(
+						common.BigToHash(big.NewInt(1)).Bytes(),
+					)
+				} else {
+					response["error"] = map[string]interface{}{
+						"code":    -32000,
+						"message": "execution reverted",
+					}
+				}
+			default:
+				response["error"] = map[string]interface{}{
+					"code":    -32601,
+					"message": "method not found",
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response)
+		},
+	))
+}
+
+func TestDiscoverOperatorContracts(t *testing.T) {
+	var tests = map[string]struct {
+		logs              []types.Log
+		expectedContracts []string
+		expectedError     bool
+	}{
+		"contracts ordered by approval": {
+			logs: []types.Log{
+				approvalLog(beaconContractA, 10),
+				approvalLog(beaconContractB, 20),
+			},
+			expectedContracts: []string{
+				beaconContractA.Hex(),
+				beaconContractB.Hex(),
+			},
+		},
+		"contract approved twice": {
+			logs: []types.Log{
+				approvalLog(beaconContractA, 10),
+				approvalLog(beaconContractA, 15),
+				approvalLog(beaconContractB, 20),
+			},
+			expectedContracts: []string{
+				beaconContractA.Hex(),
+				beaconContractB.Hex(),
+			},
+		},
+		"non-beacon contract skipped": {
+			logs: []types.Log{
+				approvalLog(beaconContractA, 10),
+				approvalLog(ecdsaFactory, 15),
+				approvalLog(beaconContractB, 20),
+			},
+			expectedContracts: []string{
+				beaconContractA.Hex(),
+				beaconContractB.Hex(),
+			},
+		},
+		"configured contract missing from registry": {
+			logs: []types.Log{
+				approvalLog(beaconContractA, 10),
+			},
+			expectedContracts: []string{
+				beaconContractA.Hex(),
+				configuredAddress.Hex(),
+			},
+		},
+		"malformed approval log": {
+			logs: []types.Log{
+				approvalLog(beaconContractA, 10),
+				{
+					Address:     registryAddress,
+					Topics:      []common.Hash{operatorContractApprovedTopic},
+					Data:        beaconContractB.Bytes(),
+					BlockNumber: 20,
+				},
+			},
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			node := newRegistryNode(
+				100,
+				test.logs,
+				[]common.Address{beaconContractA, beaconContractB},
+			)
+			defer node.Close()
+
+			client, err := NewFailoverClient(
+				context.Background(),
+				[]Endpoint{{URL: node.URL}},
+				time.Second,
+				0,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			logs := newChunkedLogSource(client, 16)
+
+			configured, err := newOperatorContract(
+				configuredAddress,
+				client,
+				logs,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ethereumClient := &EthereumClient{
+				client:                  client,
+				operatorContractAddress: configuredAddress,
+				operatorContracts:       []*operatorContract{configured},
+				logs:                    logs,
+			}
+
+			err = ethereumClient.DiscoverOperatorContracts(
+				context.Background(),
+				registryAddress.Hex(),
+				0,
+			)
+			if test.expectedError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if !strings.Contains(err.Error(), "malformed") {
+					t.Errorf("unexpected error: [%v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := ethereumClient.OperatorContracts()
+			if !reflect.DeepEqual(test.expectedContracts, actual) {
+				t.Errorf(
+					"unexpected operator contracts\n"+
+						"expected: [%v]\nactual:   [%v]",
+					test.expectedContracts,
+					actual,
+				)
+			}
+		})
+	}
+}
+
+func TestGroupActiveTime(t *testing.T) {
+	node := newRegistryNode(100, nil, []common.Address{beaconContractA})
+	defer node.Close()
+
+	client, err := NewFailoverClient(
+		context.Background(),
+		[]Endpoint{{URL: node.URL}},
+		time.Second,
+		0,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ethereumClient := &EthereumClient{client: client}
+
+	var tests = map[string]struct {
+		address  common.Address
+		expected uint64
+	}{
+		"contract exposing the group active time": {
+			address:  beaconContractA,
+			expected: 1,
+		},
+		"contract not exposing the group active time": {
+			address:  ecdsaFactory,
+			expected: defaultGroupActiveTime,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			actual, err := ethereumClient.groupActiveTime(
+				context.Background(),
+				&operatorContract{address: test.address},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != test.expected {
+				t.Errorf(
+					"unexpected group active time\n"+
+						"expected: [%v]\nactual:   [%v]",
+					test.expected,
+					actual,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	erc20abi "github.com/boar-network/keep-billings/pkg/chain/gen/erc20/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// transferMethodID selects the ERC20 transfer(address,uint256) method.
+var transferMethodID = crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+
+// token is an ERC20 token with its metadata read from the token contract.
+type token struct {
+	address  common.Address
+	symbol   string
+	decimals uint8
+
+	caller   *erc20abi.TokenCaller
+	filterer *erc20abi.TokenFilterer
+}
+
+func newToken(
+	ctx context.Context,
+	address common.Address,
+	client *FailoverClient,
+	logs bind.ContractFilterer,
+) (*token, error) {
+	if err := verifyContractCode(ctx, client, address); err != nil {
+		return nil, err
+	}
+
+	caller, err := erc20abi.NewTokenCaller(address, client)
+	if err != nil {
+		return nil, err
+	}
+
+	filterer, err := erc20abi.NewTokenFilterer(address, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol, err := caller.Symbol(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+
+	decimals, err := caller.Decimals(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token{
+		address:  address,
+		symbol:   symbol,
+		decimals: decimals,
+		caller:   caller,
+		filterer: filterer,
+	}, nil
+}
+
+// toUnits converts the amount expressed in the smallest token denomination
+// to whole tokens according to the token decimals.
+func (t *token) toUnits(amount *big.Int) *big.Float {
+	return ToTokenUnits(amount, t.decimals)
+}
+
+func (t *token) balanceOf(
+	opts *bind.CallOpts,
+	address string,
+) (*big.Float, error) {
+	balance, err := t.caller.BalanceOf(opts, common.HexToAddress(address))
+	if err != nil {
+		return nil, err
+	}
+
+	return t.toUnits(balance), nil
+}
+
+// token returns the token with the given address, reading its metadata from
+// the chain when the token is used for the first time.
+func (ec *EthereumClient) token(
+	ctx context.Context,
+	address string,
+) (*token, error) {
+	tokenAddress := common.HexToAddress(address)
+
+	if cached, ok := ec.tokens[tokenAddress]; ok {
+		return cached, nil
+	}
+
+	token, err := newToken(ctx, tokenAddress, ec.client, ec.logs)
+	if err != nil {
+		return nil, err
+	}
+
+	ec.tokens[tokenAddress] = token
+
+	return token, nil
+}
+
+func (ec *EthereumClient) TokenSymbol(
+	ctx context.Context,
+	tokenAddress string,
+) (string, error) {
+	token, err := ec.token(ctx, tokenAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return token.symbol, nil
+}
+
+func (ec *EthereumClient) TokenBalance(
+	ctx context.Context,
+	tokenAddress string,
+	address string,
+) (*big.Float, error) {
+	token, err := ec.token(ctx, tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.balanceOf(ec.callOpts(ctx), address)
+}
+
+func ToTokenUnits(amount *big.Int, decimals uint8) *big.Float {
+	denomination := new(big.Int).Exp(
+		big.NewInt(10),
+		big.NewInt(int64(decimals)),
+		nil,
+	)
+
+	return new(big.Float).Quo(
+		new(big.Float).SetInt(amount),
+		new(big.Float).SetInt(denomination),
+	)
+}
+
+// FromTokenUnits converts the decimal amount of whole tokens to the smallest
+// token denomination according to the token decimals. Digits beyond the
+// token precision are truncated.
+func FromTokenUnits(amount string, decimals uint8) (*big.Int, error) {
+	units, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount [%v]", amount)
+	}
+
+	denomination := new(big.Int).Exp(
+		big.NewInt(10),
+		big.NewInt(int64(decimals)),
+		nil,
+	)
+
+	baseUnits := new(big.Rat).Mul(units, new(big.Rat).SetInt(denomination))
+
+	return new(big.Int).Quo(baseUnits.Num(), baseUnits.Denom()), nil
+}
+
+// TransferData returns the call data of the ERC20 transfer of the amount,
+// in the smallest token denomination, to the recipient.
+func TransferData(recipient string, amount *big.Int) []byte {
+	data := make([]byte, 0, 4+32+32)
+	data = append(data, transferMethodID...)
+	data = append(
+		data,
+		common.LeftPadBytes(common.HexToAddress(recipient).Bytes(), 32)...,
+	)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+
+	return data
+}
@@ -0,0 +1,112 @@
+package chain
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestToTokenUnits(t *testing.T) {
+	tests := map[string]struct {
+		amount   *big.Int
+		decimals uint8
+
+		expectedUnits string
+	}{
+		"18 decimals": {
+			amount:        big.NewInt(1500000000000000000),
+			decimals:      18,
+			expectedUnits: "1.500000",
+		},
+		"6 decimals": {
+			amount:        big.NewInt(2750000),
+			decimals:      6,
+			expectedUnits: "2.750000",
+		},
+		"no decimals": {
+			amount:        big.NewInt(42),
+			decimals:      0,
+			expectedUnits: "42.000000",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			units := ToTokenUnits(test.amount, test.decimals).Text('f', 6)
+
+			if units != test.expectedUnits {
+				t.Errorf(
+					"unexpected token units\nexpected: [%v]\nactual:   [%v]",
+					test.expectedUnits,
+					units,
+				)
+			}
+		})
+	}
+}
+
+func TestFromTokenUnits(t *testing.T) {
+	tests := map[string]struct {
+		amount   string
+		decimals uint8
+
+		expectedBaseUnits string
+	}{
+		"18 decimals": {
+			amount:            "1.500000",
+			decimals:          18,
+			expectedBaseUnits: "1500000000000000000",
+		},
+		"6 decimals": {
+			amount:            "2.750000",
+			decimals:          6,
+			expectedBaseUnits: "2750000",
+		},
+		"truncated beyond precision": {
+			amount:            "2.7500009",
+			decimals:          6,
+			expectedBaseUnits: "2750000",
+		},
+		"large amount": {
+			amount:            "123456789.123456",
+			decimals:          18,
+			expectedBaseUnits: "123456789123456000000000000",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			baseUnits, err := FromTokenUnits(test.amount, test.decimals)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if baseUnits.String() != test.expectedBaseUnits {
+				t.Errorf(
+					"unexpected base units\nexpected: [%v]\nactual:   [%v]",
+					test.expectedBaseUnits,
+					baseUnits.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestTransferData(t *testing.T) {
+	data := TransferData(
+		"0x00000000000000000000000000000000000000aa",
+		big.NewInt(1000),
+	)
+
+	expectedData := "a9059cbb" +
+		"00000000000000000000000000000000000000000000000000000000000000aa" +
+		"00000000000000000000000000000000000000000000000000000000000003e8"
+
+	if hex.EncodeToString(data) != expectedData {
+		t.Errorf(
+			"unexpected transfer data\nexpected: [%v]\nactual:   [%x]",
+			expectedData,
+			data,
+		)
+	}
+}
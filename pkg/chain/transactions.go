@@ -0,0 +1,128 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TransactionCost is the fee paid by the sender of a mined transaction.
+type TransactionCost struct {
+	TxHash      string
+	BlockNumber uint64
+	From        string
+	GasUsed     uint64
+	// gas price in wei
+	GasPrice *big.Int
+	// fee in ETH
+	Fee *big.Float
+}
+
+// TransactionCost returns the sender and the fee of the given transaction.
+func (ec *EthereumClient) TransactionCost(
+	ctx context.Context,
+	txHash string,
+) (*TransactionCost, error) {
+	hash := common.HexToHash(txHash)
+
+	tx, _, err := ec.client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction: [%v]", err)
+	}
+
+	receipt, err := ec.client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction receipt: [%v]", err)
+	}
+
+	sender, err := ec.client.TransactionSender(
+		ctx,
+		tx,
+		receipt.BlockHash,
+		receipt.TransactionIndex,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction sender: [%v]", err)
+	}
+
+	feeWei := new(big.Int).Mul(
+		new(big.Int).SetUint64(receipt.GasUsed),
+		tx.GasPrice(),
+	)
+
+	return &TransactionCost{
+		TxHash:      hash.Hex(),
+		BlockNumber: receipt.BlockNumber.Uint64(),
+		From:        sender.Hex(),
+		GasUsed:     receipt.GasUsed,
+		GasPrice:    tx.GasPrice(),
+		Fee:         WeiToEth(feeWei),
+	}, nil
+}
+
+// ChainID returns the chain ID served by the node.
+func (ec *EthereumClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return ec.client.ChainID(ctx)
+}
+
+// PendingNonce returns the nonce of the next transaction of the account,
+// including transactions pending in the node.
+func (ec *EthereumClient) PendingNonce(
+	ctx context.Context,
+	address string,
+) (uint64, error) {
+	return ec.client.PendingNonceAt(ctx, common.HexToAddress(address))
+}
+
+// SuggestGasPrice returns the gas price in wei suggested by the node.
+func (ec *EthereumClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return ec.client.SuggestGasPrice(ctx)
+}
+
+// EstimateGas returns the gas needed to execute the transaction against
+// the current state.
+func (ec *EthereumClient) EstimateGas(
+	ctx context.Context,
+	from string,
+	to string,
+	value *big.Int,
+	data []byte,
+) (uint64, error) {
+	toAddress := common.HexToAddress(to)
+
+	return ec.client.EstimateGas(ctx, ethereum.CallMsg{
+		From:  common.HexToAddress(from),
+		To:    &toAddress,
+		Value: value,
+		Data:  data,
+	})
+}
+
+// SendTransaction broadcasts the signed transaction.
+func (ec *EthereumClient) SendTransaction(
+	ctx context.Context,
+	tx *types.Transaction,
+) error {
+	return ec.client.SendTransaction(ctx, tx)
+}
+
+// IsTransactionKnown checks whether the network knows the transaction,
+// either pending or mined.
+func (ec *EthereumClient) IsTransactionKnown(
+	ctx context.Context,
+	txHash string,
+) (bool, error) {
+	_, _, err := ec.client.TransactionByHash(ctx, common.HexToHash(txHash))
+	if err == ethereum.NotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
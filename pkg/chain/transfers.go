@@ -0,0 +1,184 @@
+package chain
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type TokenTransfer struct {
+	BlockNumber uint64
+	LogIndex    uint
+	TxHash      string
+	From        string
+	To          string
+	Amount      *big.Float
+}
+
+// IncomingKeepTransfers returns all KEEP transfers to the given recipient
+// made by any of the given senders in the given block range, ordered as they
+// were made.
+func (ec *EthereumClient) IncomingKeepTransfers(
+	ctx context.Context,
+	recipient string,
+	senders []string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*TokenTransfer, error) {
+	return ec.incomingTransfers(
+		ctx,
+		ec.keepToken,
+		recipient,
+		senders,
+		startBlock,
+		endBlock,
+	)
+}
+
+// IncomingTokenTransfers returns all transfers of the given token to the
+// given recipient made by any of the given senders in the given block range,
+// ordered as they were made.
+func (ec *EthereumClient) IncomingTokenTransfers(
+	ctx context.Context,
+	tokenAddress string,
+	recipient string,
+	senders []string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*TokenTransfer, error) {
+	token, err := ec.token(ctx, tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return ec.incomingTransfers(
+		ctx,
+		token,
+		recipient,
+		senders,
+		startBlock,
+		endBlock,
+	)
+}
+
+func (ec *EthereumClient) incomingTransfers(
+	ctx context.Context,
+	token *token,
+	recipient string,
+	senders []string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*TokenTransfer, error) {
+	if len(senders) == 0 {
+		return []*TokenTransfer{}, nil
+	}
+
+	senderAddresses := make([]common.Address, len(senders))
+	for i, sender := range senders {
+		senderAddresses[i] = common.HexToAddress(sender)
+	}
+
+	return ec.tokenTransfers(
+		ctx,
+		token,
+		senderAddresses,
+		[]common.Address{common.HexToAddress(recipient)},
+		startBlock,
+		endBlock,
+	)
+}
+
+// KeepTransfers returns all KEEP transfers to and from the given address
+// made in the given block range, ordered as they were made.
+func (ec *EthereumClient) KeepTransfers(
+	ctx context.Context,
+	address string,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*TokenTransfer, error) {
+	addressFilter := []common.Address{common.HexToAddress(address)}
+
+	outgoing, err := ec.tokenTransfers(
+		ctx,
+		ec.keepToken,
+		addressFilter,
+		nil,
+		startBlock,
+		endBlock,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, err := ec.tokenTransfers(
+		ctx,
+		ec.keepToken,
+		nil,
+		addressFilter,
+		startBlock,
+		endBlock,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := append(outgoing, incoming...)
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+
+	// a transfer to self is returned by both queries
+	unique := make([]*TokenTransfer, 0, len(transfers))
+	for i, transfer := range transfers {
+		if i > 0 &&
+			transfers[i-1].BlockNumber == transfer.BlockNumber &&
+			transfers[i-1].LogIndex == transfer.LogIndex {
+			continue
+		}
+		unique = append(unique, transfer)
+	}
+
+	return unique, nil
+}
+
+func (ec *EthereumClient) tokenTransfers(
+	ctx context.Context,
+	token *token,
+	from []common.Address,
+	to []common.Address,
+	startBlock uint64,
+	endBlock uint64,
+) ([]*TokenTransfer, error) {
+	iterator, err := token.filterer.FilterTransfer(
+		filterOpts(ctx, startBlock, endBlock),
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	transfers := make([]*TokenTransfer, 0)
+	for iterator.Next() {
+		transfers = append(transfers, &TokenTransfer{
+			BlockNumber: iterator.Event.Raw.BlockNumber,
+			LogIndex:    iterator.Event.Raw.Index,
+			TxHash:      iterator.Event.Raw.TxHash.Hex(),
+			From:        iterator.Event.From.Hex(),
+			To:          iterator.Event.To.Hex(),
+			Amount:      token.toUnits(iterator.Event.Tokens),
+		})
+	}
+	if err := iterator.Error(); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,202 @@
+// Package chart renders simple charts as inline SVG so they can be embedded
+// in HTML reports rendered without JavaScript or network access.
+package chart
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Chart dimensions in pixels.
+const (
+	width        = 640
+	height       = 260
+	marginTop    = 36
+	marginRight  = 16
+	marginBottom = 40
+	marginLeft   = 72
+
+	plotWidth  = width - marginLeft - marginRight
+	plotHeight = height - marginTop - marginBottom
+
+	gridLines = 4
+
+	// labels are skipped if there are more values so they don't overlap
+	maxLabels = 12
+)
+
+// DefaultColor is used to draw charts if no color is given.
+const DefaultColor = "#4a6fa5"
+
+// BarChart renders the values as bars with labels under them. Negative
+// values are presented as zero.
+func BarChart(title string, labels []string, values []float64, color string) string {
+	return render(title, labels, values, color, func(svg *strings.Builder, y func(float64) float64) {
+		slot := float64(plotWidth) / float64(len(values))
+		barWidth := slot * 0.6
+
+		for i, value := range values {
+			top := y(value)
+			fmt.Fprintf(
+				svg,
+				`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%v"/>`,
+				float64(marginLeft)+slot*float64(i)+(slot-barWidth)/2,
+				top,
+				barWidth,
+				float64(marginTop+plotHeight)-top,
+				html.EscapeString(color),
+			)
+		}
+	})
+}
+
+// LineChart renders the values as a line with markers and labels under
+// them. Negative values are presented as zero.
+func LineChart(title string, labels []string, values []float64, color string) string {
+	return render(title, labels, values, color, func(svg *strings.Builder, y func(float64) float64) {
+		points := make([]string, len(values))
+		for i, value := range values {
+			points[i] = fmt.Sprintf("%.1f,%.1f", x(i, len(values)), y(value))
+		}
+
+		fmt.Fprintf(
+			svg,
+			`<polyline points="%v" fill="none" stroke="%v" stroke-width="2"/>`,
+			strings.Join(points, " "),
+			html.EscapeString(color),
+		)
+
+		for i, value := range values {
+			fmt.Fprintf(
+				svg,
+				`<circle cx="%.1f" cy="%.1f" r="3" fill="%v"/>`,
+				x(i, len(values)),
+				y(value),
+				html.EscapeString(color),
+			)
+		}
+	})
+}
+
+// render draws the frame common to all charts, that is, the title, the
+// value grid and the labels, and calls draw to present the values.
+func render(
+	title string,
+	labels []string,
+	values []float64,
+	color string,
+	draw func(svg *strings.Builder, y func(float64) float64),
+) string {
+	if len(color) == 0 {
+		color = DefaultColor
+	}
+
+	maxValue := 0.0
+	for _, value := range values {
+		maxValue = math.Max(maxValue, value)
+	}
+	top, step := scale(maxValue)
+
+	y := func(value float64) float64 {
+		return float64(marginTop+plotHeight) -
+			math.Max(0, value)/top*float64(plotHeight)
+	}
+
+	svg := &strings.Builder{}
+	fmt.Fprintf(
+		svg,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" `+
+			`viewBox="0 0 %v %v" font-family="sans-serif" font-size="11">`,
+		width,
+		height,
+		width,
+		height,
+	)
+
+	fmt.Fprintf(
+		svg,
+		`<text x="%v" y="20" font-size="14" font-weight="bold">%v</text>`,
+		marginLeft,
+		html.EscapeString(title),
+	)
+
+	for i := 0; i <= gridLines; i++ {
+		value := step * float64(i)
+		fmt.Fprintf(
+			svg,
+			`<line x1="%v" y1="%.1f" x2="%v" y2="%.1f" stroke="#dddddd"/>`+
+				`<text x="%v" y="%.1f" text-anchor="end">%v</text>`,
+			marginLeft,
+			y(value),
+			width-marginRight,
+			y(value),
+			marginLeft-6,
+			y(value)+4,
+			formatTick(value, step),
+		)
+	}
+
+	if len(values) > 0 {
+		draw(svg, y)
+	}
+
+	stride := (len(labels) + maxLabels - 1) / maxLabels
+	for i, label := range labels {
+		if i%stride != 0 && i != len(labels)-1 {
+			continue
+		}
+
+		fmt.Fprintf(
+			svg,
+			`<text x="%.1f" y="%v" text-anchor="middle">%v</text>`,
+			x(i, len(labels)),
+			marginTop+plotHeight+18,
+			html.EscapeString(label),
+		)
+	}
+
+	svg.WriteString(`</svg>`)
+
+	return svg.String()
+}
+
+// x returns the horizontal center of the i-th of n values.
+func x(i int, n int) float64 {
+	slot := float64(plotWidth) / float64(n)
+	return float64(marginLeft) + slot*float64(i) + slot/2
+}
+
+// scale returns the top of the value axis and the step between grid lines,
+// rounded to 1, 2, 2.5 or 5 times a power of ten.
+func scale(maxValue float64) (float64, float64) {
+	if maxValue <= 0 {
+		return 1, 1.0 / gridLines
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(maxValue/gridLines)))
+
+	step := 10 * magnitude
+	for _, multiplier := range []float64{1, 2, 2.5, 5} {
+		if multiplier*magnitude*gridLines >= maxValue {
+			step = multiplier * magnitude
+			break
+		}
+	}
+
+	return step * gridLines, step
+}
+
+func formatTick(value float64, step float64) string {
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+		if step*math.Pow(10, float64(decimals)) != math.Trunc(step*math.Pow(10, float64(decimals))) {
+			decimals++
+		}
+	}
+
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
@@ -0,0 +1,107 @@
+package chart
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestCharts(t *testing.T) {
+	var tests = map[string]struct {
+		render          func(string, []string, []float64, string) string
+		labels          []string
+		values          []float64
+		expectedElement string
+		expectedCount   int
+	}{
+		"bar chart": {
+			render:          BarChart,
+			labels:          []string{"2020-10-01", "2020-11-01", "2020-12-01"},
+			values:          []float64{0.5, 1.25, 0},
+			expectedElement: "<rect ",
+			expectedCount:   3,
+		},
+		"line chart": {
+			render:          LineChart,
+			labels:          []string{"2020-10-01", "2020-11-01"},
+			values:          []float64{100000, 150000},
+			expectedElement: "<circle ",
+			expectedCount:   2,
+		},
+		"chart without values": {
+			render:          LineChart,
+			labels:          []string{},
+			values:          []float64{},
+			expectedElement: "<circle ",
+			expectedCount:   0,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			svg := test.render("Rewards <ETH> & more", test.labels, test.values, "")
+
+			if err := xml.Unmarshal([]byte(svg), new(interface{})); err != nil {
+				t.Fatalf("chart is not a valid SVG document: [%v]", err)
+			}
+
+			if !strings.Contains(svg, "Rewards &lt;ETH&gt; &amp; more") {
+				t.Errorf("chart title is not escaped\nactual: [%v]", svg)
+			}
+
+			count := strings.Count(svg, test.expectedElement)
+			if count != test.expectedCount {
+				t.Errorf(
+					"unexpected number of elements\nexpected: [%v]\nactual:   [%v]",
+					test.expectedCount,
+					count,
+				)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	var tests = map[string]struct {
+		maxValue     float64
+		expectedTop  float64
+		expectedStep float64
+	}{
+		"no values": {
+			maxValue:     0,
+			expectedTop:  1,
+			expectedStep: 0.25,
+		},
+		"fractional values": {
+			maxValue:     1.3,
+			expectedTop:  2,
+			expectedStep: 0.5,
+		},
+		"large values": {
+			maxValue:     150000,
+			expectedTop:  200000,
+			expectedStep: 50000,
+		},
+		"exact grid": {
+			maxValue:     400,
+			expectedTop:  400,
+			expectedStep: 100,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			top, step := scale(test.maxValue)
+
+			if top != test.expectedTop || step != test.expectedStep {
+				t.Errorf(
+					"unexpected scale\nexpected: [%v %v]\nactual:   [%v %v]",
+					test.expectedTop,
+					test.expectedStep,
+					top,
+					step,
+				)
+			}
+		})
+	}
+}
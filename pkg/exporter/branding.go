@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"path/filepath"
+	"regexp"
+)
+
+// cssColorPattern matches hex and named CSS colors. Colors are inserted
+// into templates unescaped so nothing else is accepted.
+var cssColorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+)$`)
+
+// Branding presents the provider issuing billings.
+type Branding struct {
+	Name    string
+	Website string
+	Address string
+	VatID   string
+	// logo image embedded as a data URI so the rendered PDF does not depend
+	// on external files; empty if not set
+	Logo         template.URL
+	PrimaryColor template.CSS
+	AccentColor  template.CSS
+}
+
+// NewBranding creates the branding reading the logo from the given image
+// file, if set. Colors have to be hex or named CSS colors.
+func NewBranding(
+	name string,
+	website string,
+	address string,
+	vatID string,
+	logoFile string,
+	primaryColor string,
+	accentColor string,
+) (*Branding, error) {
+	branding := &Branding{
+		Name:    name,
+		Website: website,
+		Address: address,
+		VatID:   vatID,
+	}
+
+	if len(logoFile) > 0 {
+		logo, err := dataURI(logoFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read logo: [%v]", err)
+		}
+		branding.Logo = logo
+	}
+
+	for _, color := range []struct {
+		value  string
+		target *template.CSS
+	}{
+		{primaryColor, &branding.PrimaryColor},
+		{accentColor, &branding.AccentColor},
+	} {
+		if len(color.value) == 0 {
+			continue
+		}
+
+		if !cssColorPattern.MatchString(color.value) {
+			return nil, fmt.Errorf("invalid color: [%v]", color.value)
+		}
+
+		*color.target = template.CSS(color.value)
+	}
+
+	return branding, nil
+}
+
+func dataURI(file string) (template.URL, error) {
+	fileBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	mediaType := mime.TypeByExtension(filepath.Ext(file))
+	if len(mediaType) == 0 {
+		return "", fmt.Errorf("unknown media type of [%v]", file)
+	}
+
+	return template.URL(
+		"data:" + mediaType + ";base64," +
+			base64.StdEncoding.EncodeToString(fileBytes),
+	), nil
+}
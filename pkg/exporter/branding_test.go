@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewBranding(t *testing.T) {
+	directory, err := ioutil.TempDir("", "branding")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	logoFile := filepath.Join(directory, "logo.png")
+	if err := ioutil.WriteFile(logoFile, []byte("logo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	unknownLogoFile := filepath.Join(directory, "logo.unknown")
+	if err := ioutil.WriteFile(unknownLogoFile, []byte("logo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		logoFile             string
+		primaryColor         string
+		accentColor          string
+		expectedLogo         template.URL
+		expectedPrimaryColor template.CSS
+		expectedAccentColor  template.CSS
+		expectedError        bool
+	}{
+		"logo embedded as data URI": {
+			logoFile:     logoFile,
+			expectedLogo: "data:image/png;base64,bG9nbw==",
+		},
+		"logo not set": {
+			expectedLogo: "",
+		},
+		"logo of unknown media type": {
+			logoFile:      unknownLogoFile,
+			expectedError: true,
+		},
+		"missing logo file": {
+			logoFile:      filepath.Join(directory, "missing.png"),
+			expectedError: true,
+		},
+		"hex and named colors": {
+			primaryColor:         "#0B3D91",
+			accentColor:          "white",
+			expectedPrimaryColor: "#0B3D91",
+			expectedAccentColor:  "white",
+		},
+		"invalid primary color": {
+			primaryColor:  "red; background: url(x)",
+			expectedError: true,
+		},
+		"invalid accent color": {
+			accentColor:   "#0B3D91}",
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			branding, err := NewBranding(
+				"Provider",
+				"https://provider.com",
+				"",
+				"",
+				test.logoFile,
+				test.primaryColor,
+				test.accentColor,
+			)
+			if test.expectedError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if branding.Logo != test.expectedLogo {
+				t.Errorf(
+					"unexpected logo\nexpected: [%v]\nactual:   [%v]",
+					test.expectedLogo,
+					branding.Logo,
+				)
+			}
+			if branding.PrimaryColor != test.expectedPrimaryColor {
+				t.Errorf(
+					"unexpected primary color\nexpected: [%v]\nactual:   [%v]",
+					test.expectedPrimaryColor,
+					branding.PrimaryColor,
+				)
+			}
+			if branding.AccentColor != test.expectedAccentColor {
+				t.Errorf(
+					"unexpected accent color\nexpected: [%v]\nactual:   [%v]",
+					test.expectedAccentColor,
+					branding.AccentColor,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/boar-network/keep-billings/pkg/assets"
+)
+
+const (
+	// DefaultLanguage catalog has to contain all labels used in templates.
+	// Labels missing in other catalogs are taken from it.
+	DefaultLanguage = "en"
+
+	thousandsSeparatorLabel = "number.thousands_separator"
+	decimalSeparatorLabel   = "number.decimal_separator"
+)
+
+// Catalog translates labels used in report templates. Labels may contain
+// fmt verbs filled with the label arguments.
+type Catalog struct {
+	Language string
+	labels   map[string]string
+}
+
+// LoadCatalog reads the catalog of the language from the `<language>.json`
+// file in the translations directory or from the bundled catalog if the
+// directory is not set or has no such file. Each catalog file is a JSON
+// object mapping label keys to translated labels.
+func LoadCatalog(directory string, language string) (*Catalog, error) {
+	labels, err := readLabels(directory, DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	if language != DefaultLanguage {
+		translated, err := readLabels(directory, language)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, label := range translated {
+			labels[key] = label
+		}
+	}
+
+	return &Catalog{language, labels}, nil
+}
+
+func readLabels(directory string, language string) (map[string]string, error) {
+	catalogFile, catalogBytes, err := readCatalogFile(directory, language)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	if err := json.Unmarshal(catalogBytes, &labels); err != nil {
+		return nil, fmt.Errorf(
+			"could not decode translations [%v]: [%v]",
+			catalogFile,
+			err,
+		)
+	}
+
+	return labels, nil
+}
+
+// readCatalogFile returns the path and the content of the catalog file,
+// preferring the one in the translations directory over the bundled one.
+func readCatalogFile(
+	directory string,
+	language string,
+) (string, []byte, error) {
+	if len(directory) > 0 {
+		catalogFile := filepath.Join(directory, language+".json")
+
+		catalogBytes, err := ioutil.ReadFile(catalogFile)
+		if err == nil {
+			return catalogFile, catalogBytes, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", nil, err
+		}
+	}
+
+	catalogFile := path.Join(assets.TranslationsDirectory, language+".json")
+
+	catalogBytes, ok := assets.File(catalogFile)
+	if !ok {
+		return "", nil, fmt.Errorf("no translations for language [%v]", language)
+	}
+
+	return catalogFile, catalogBytes, nil
+}
+
+// Label returns the translated label. The key is returned if the label
+// is not translated, so missing translations are visible in the report.
+func (c *Catalog) Label(key string, args ...interface{}) string {
+	label, ok := c.labels[key]
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(label, args...)
+	}
+
+	return label
+}
+
+func (c *Catalog) separators() (string, string) {
+	thousands, ok := c.labels[thousandsSeparatorLabel]
+	if !ok {
+		thousands = ","
+	}
+
+	decimal, ok := c.labels[decimalSeparatorLabel]
+	if !ok {
+		decimal = "."
+	}
+
+	return thousands, decimal
+}
@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalogLabel(t *testing.T) {
+	directory, err := ioutil.TempDir("", "translations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	// the directory catalog translates only the title
+	err = ioutil.WriteFile(
+		filepath.Join(directory, "de.json"),
+		[]byte(`{"header.title": "Eigener Bericht"}`),
+		0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		directory     string
+		language      string
+		key           string
+		args          []interface{}
+		expectedLabel string
+	}{
+		"bundled english label": {
+			language:      "en",
+			key:           "header.title",
+			expectedLabel: "Keep Random Beacon Staking Report",
+		},
+		"bundled translated label": {
+			language:      "de",
+			key:           "header.title",
+			expectedLabel: "Keep Random Beacon Staking-Bericht",
+		},
+		"label with arguments": {
+			language:      "pl",
+			key:           "header.reporting_period",
+			args:          []interface{}{100, 200},
+			expectedLabel: "Okres rozliczeniowy: bloki 100 – 200",
+		},
+		"directory catalog overriding the bundled one": {
+			directory:     directory,
+			language:      "de",
+			key:           "header.title",
+			expectedLabel: "Eigener Bericht",
+		},
+		"english fallback of a label missing in the directory catalog": {
+			directory:     directory,
+			language:      "de",
+			key:           "yes",
+			expectedLabel: "Yes",
+		},
+		"bundled catalog of a language missing in the directory": {
+			directory:     directory,
+			language:      "pl",
+			key:           "yes",
+			expectedLabel: "Tak",
+		},
+		"unknown label": {
+			language:      "en",
+			key:           "unknown.label",
+			expectedLabel: "unknown.label",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			catalog, err := LoadCatalog(test.directory, test.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			label := catalog.Label(test.key, test.args...)
+			if label != test.expectedLabel {
+				t.Errorf(
+					"unexpected label\nexpected: [%v]\nactual:   [%v]",
+					test.expectedLabel,
+					label,
+				)
+			}
+		})
+	}
+}
+
+func TestLoadCatalogOfUnknownLanguage(t *testing.T) {
+	if _, err := LoadCatalog("", "xx"); err == nil {
+		t.Fatal("expected error for unknown language")
+	}
+}
@@ -0,0 +1,34 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+type CsvExporter struct {
+	records func(data interface{}) ([][]string, error)
+}
+
+// NewCsvExporter creates an exporter writing records extracted from the
+// report data by the given function as a CSV file.
+func NewCsvExporter(
+	records func(data interface{}) ([][]string, error),
+) *CsvExporter {
+	return &CsvExporter{records}
+}
+
+func (ce *CsvExporter) Export(data interface{}) ([]byte, error) {
+	records, err := ce.records(data)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,6 @@
+package exporter
+
+// Exporter turns report data into the content of an output file.
+type Exporter interface {
+	Export(data interface{}) ([]byte, error)
+}
@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/chart"
+)
+
+// defaultExplorerURL is the block explorer linked from templates if no
+// other explorer is configured.
+const defaultExplorerURL = "https://etherscan.io"
+
+// TemplateFuncs returns functions available in report templates. Links
+// point to the given block explorer, Etherscan if not set. Labels and
+// amounts are presented according to the catalog and charts are drawn in
+// the primary color of the branding.
+func TemplateFuncs(
+	explorerURL string,
+	branding *Branding,
+	catalog *Catalog,
+) template.FuncMap {
+	if len(explorerURL) == 0 {
+		explorerURL = defaultExplorerURL
+	}
+	explorerURL = strings.TrimSuffix(explorerURL, "/")
+
+	thousandsSeparator, decimalSeparator := catalog.separators()
+
+	chartColor := chart.DefaultColor
+	if branding != nil && len(branding.PrimaryColor) > 0 {
+		chartColor = string(branding.PrimaryColor)
+	}
+
+	return template.FuncMap{
+		"branding": func() *Branding {
+			return branding
+		},
+		"label": catalog.Label,
+		"formatAmount": func(amount string) string {
+			return formatAmount(amount, thousandsSeparator, decimalSeparator)
+		},
+		"shortAddress": shortAddress,
+		"formatDate":   formatDate,
+		"blockURL": func(blockNumber interface{}) string {
+			return fmt.Sprintf("%v/block/%v", explorerURL, blockNumber)
+		},
+		"txURL": func(txHash string) string {
+			return fmt.Sprintf("%v/tx/%v", explorerURL, txHash)
+		},
+		"addressURL": func(address string) string {
+			return fmt.Sprintf("%v/address/%v", explorerURL, address)
+		},
+		"barChart": func(
+			title string,
+			labels []string,
+			values []float64,
+		) template.HTML {
+			return template.HTML(chart.BarChart(title, labels, values, chartColor))
+		},
+		"lineChart": func(
+			title string,
+			labels []string,
+			values []float64,
+		) template.HTML {
+			return template.HTML(chart.LineChart(title, labels, values, chartColor))
+		},
+	}
+}
+
+// formatAmount separates thousands of the decimal amount and replaces its
+// decimal point with the given separators, for example 1234567.5 becomes
+// 1,234,567.5 in English. Values which are not decimal numbers are returned
+// unchanged.
+func formatAmount(
+	amount string,
+	thousandsSeparator string,
+	decimalSeparator string,
+) string {
+	sign := ""
+	unsigned := amount
+	if strings.HasPrefix(unsigned, "-") || strings.HasPrefix(unsigned, "+") {
+		sign, unsigned = unsigned[:1], unsigned[1:]
+	}
+
+	integer, fraction := unsigned, ""
+	if dot := strings.Index(unsigned, "."); dot >= 0 {
+		integer, fraction = unsigned[:dot], unsigned[dot+1:]
+	}
+
+	if len(integer) == 0 || !isDigits(integer) || !isDigits(fraction) {
+		return amount
+	}
+
+	grouped := &strings.Builder{}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(thousandsSeparator)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	if strings.Contains(unsigned, ".") {
+		return sign + grouped.String() + decimalSeparator + fraction
+	}
+
+	return sign + grouped.String()
+}
+
+func isDigits(value string) bool {
+	for _, character := range value {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// shortAddress shortens the address or hash to its first and last four
+// hex digits, for example 0x1234...cdef.
+func shortAddress(address string) string {
+	if len(address) <= 12 {
+		return address
+	}
+
+	return address[:6] + "..." + address[len(address)-4:]
+}
+
+// formatDate reformats the date presented in the report using the given
+// layout. Values which are not report dates are returned unchanged.
+func formatDate(layout string, date string) string {
+	parsed, err := time.Parse(billing.TimeLayout, date)
+	if err != nil {
+		return date
+	}
+
+	return parsed.Format(layout)
+}
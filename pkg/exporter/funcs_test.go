@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"testing"
+)
+
+func TestFormatAmount(t *testing.T) {
+	var tests = map[string]struct {
+		amount             string
+		thousandsSeparator string
+		decimalSeparator   string
+		expectedAmount     string
+	}{
+		"less than thousand": {
+			amount:             "999.25",
+			thousandsSeparator: ",",
+			decimalSeparator:   ".",
+			expectedAmount:     "999.25",
+		},
+		"millions with fraction": {
+			amount:             "1234567.891",
+			thousandsSeparator: ",",
+			decimalSeparator:   ".",
+			expectedAmount:     "1,234,567.891",
+		},
+		"german separators": {
+			amount:             "1234567.891",
+			thousandsSeparator: ".",
+			decimalSeparator:   ",",
+			expectedAmount:     "1.234.567,891",
+		},
+		"negative thousands": {
+			amount:             "-300000",
+			thousandsSeparator: ",",
+			decimalSeparator:   ".",
+			expectedAmount:     "-300,000",
+		},
+		"not a number": {
+			amount:             "n/a",
+			thousandsSeparator: ",",
+			decimalSeparator:   ".",
+			expectedAmount:     "n/a",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			amount := formatAmount(
+				test.amount,
+				test.thousandsSeparator,
+				test.decimalSeparator,
+			)
+
+			if amount != test.expectedAmount {
+				t.Errorf(
+					"unexpected amount\nexpected: [%v]\nactual:   [%v]",
+					test.expectedAmount,
+					amount,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package exporter
+
+import (
+	"encoding/json"
+)
+
+type JsonExporter struct{}
+
+// NewJsonExporter creates an exporter writing the report data as compact
+// JSON. Struct fields are written in the declaration order and map keys
+// are sorted so the same report always gives the same bytes.
+func NewJsonExporter() *JsonExporter {
+	return &JsonExporter{}
+}
+
+func (je *JsonExporter) Export(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
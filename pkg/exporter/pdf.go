@@ -2,20 +2,71 @@ package exporter
 
 import (
 	"bytes"
-	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
+	"fmt"
 	"html/template"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
+	"github.com/boar-network/keep-billings/pkg/assets"
 )
 
 type PdfExporter struct {
 	pdfTemplate *template.Template
 }
 
-func NewPdfExporter(templateFilename string) (*PdfExporter, error) {
-	pdfTemplate, err := template.ParseFiles(templateFilename)
-	if err != nil {
+// NewPdfExporter parses the template file, or the given bundled template
+// if the file is not set, along with partials. Partials are HTML files
+// defining named templates, like a header or a footer, shared by all report
+// templates. Bundled partials are always available and partials found in
+// the partials directory, if set, override them.
+func NewPdfExporter(
+	templateFilename string,
+	bundledTemplate string,
+	partialsDirectory string,
+	funcs template.FuncMap,
+) (*PdfExporter, error) {
+	pdfTemplate := template.New("billing").Funcs(funcs)
+
+	for _, name := range assets.Names(assets.PartialsDirectory) {
+		partial, _ := assets.File(name)
+		if _, err := pdfTemplate.New(name).Parse(string(partial)); err != nil {
+			return nil, fmt.Errorf(
+				"could not parse bundled partial [%v]: [%v]",
+				name,
+				err,
+			)
+		}
+	}
+
+	content, ok := assets.File(bundledTemplate)
+	if len(templateFilename) > 0 {
+		var err error
+		content, err = ioutil.ReadFile(templateFilename)
+		if err != nil {
+			return nil, err
+		}
+	} else if !ok {
+		return nil, fmt.Errorf("no bundled template [%v]", bundledTemplate)
+	}
+
+	if _, err := pdfTemplate.Parse(string(content)); err != nil {
 		return nil, err
 	}
 
+	if len(partialsDirectory) > 0 {
+		partials, err := filepath.Glob(filepath.Join(partialsDirectory, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(partials) > 0 {
+			if _, err := pdfTemplate.ParseFiles(partials...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return &PdfExporter{pdfTemplate}, nil
 }
 
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+)
+
+// tsvReplacer keeps field values on a single line and in a single column.
+var tsvReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+type TsvExporter struct {
+	records func(data interface{}) ([][]string, error)
+}
+
+// NewTsvExporter creates an exporter writing records extracted from the
+// report data by the given function as a tab-separated file. Unlike CSV,
+// fields are never quoted, as expected by QuickBooks IIF imports, so tabs
+// and line breaks in field values are replaced with spaces.
+func NewTsvExporter(
+	records func(data interface{}) ([][]string, error),
+) *TsvExporter {
+	return &TsvExporter{records}
+}
+
+func (te *TsvExporter) Export(data interface{}) ([]byte, error) {
+	records, err := te.records(data)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+
+	for _, record := range records {
+		for i, field := range record {
+			if i > 0 {
+				buffer.WriteString("\t")
+			}
+			buffer.WriteString(tsvReplacer.Replace(field))
+		}
+		buffer.WriteString("\r\n")
+	}
+
+	return buffer.Bytes(), nil
+}
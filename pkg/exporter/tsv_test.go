@@ -0,0 +1,34 @@
+package exporter
+
+import (
+	"testing"
+)
+
+func TestTsvExporter(t *testing.T) {
+	tsvExporter := NewTsvExporter(
+		func(data interface{}) ([][]string, error) {
+			return data.([][]string), nil
+		},
+	)
+
+	content, err := tsvExporter.Export([][]string{
+		{"!TRNS", "MEMO"},
+		{"TRNS", "tab\there, \"quoted\"\nand broken"},
+		{"ENDTRNS"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedContent := "!TRNS\tMEMO\r\n" +
+		"TRNS\ttab here, \"quoted\" and broken\r\n" +
+		"ENDTRNS\r\n"
+
+	if string(content) != expectedContent {
+		t.Errorf(
+			"unexpected content\nexpected: [%q]\nactual:   [%q]",
+			expectedContent,
+			string(content),
+		)
+	}
+}
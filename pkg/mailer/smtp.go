@@ -0,0 +1,258 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendTimeout limits sending of a single message, including connecting to
+// the server, so an unresponsive server never blocks the run.
+const sendTimeout = 2 * time.Minute
+
+// Attachment is a file attached to the message.
+type Attachment struct {
+	Name    string
+	Content []byte
+}
+
+// Message is a plain text email with attachments.
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []*Attachment
+}
+
+// SmtpMailer sends messages through an SMTP server. The connection is
+// upgraded with STARTTLS if the server supports it.
+type SmtpMailer struct {
+	host    string
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+// NewSmtpMailer creates a mailer sending messages from the given address.
+// Messages are sent without authentication if the username is not set.
+func NewSmtpMailer(
+	host string,
+	port int,
+	username string,
+	password string,
+	from string,
+) *SmtpMailer {
+	var auth smtp.Auth
+	if len(username) > 0 {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SmtpMailer{
+		host:    host,
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:    auth,
+		from:    from,
+	}
+}
+
+// Send sends the message. Sending is aborted when the context is done or
+// takes longer than the send timeout.
+func (sm *SmtpMailer) Send(ctx context.Context, message *Message) error {
+	if len(message.To) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	messageBytes, err := sm.compose(message)
+	if err != nil {
+		return fmt.Errorf("could not compose message: [%v]", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", sm.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	// unblock the SMTP session as soon as the context is cancelled
+	sent := make(chan struct{})
+	defer close(sent)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-sent:
+		}
+	}()
+
+	if err := sm.sendMail(conn, message.To, messageBytes); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// sendMail runs the SMTP session over the connection the same way
+// smtp.SendMail does.
+func (sm *SmtpMailer) sendMail(
+	conn net.Conn,
+	to []string,
+	messageBytes []byte,
+) error {
+	client, err := smtp.NewClient(conn, sm.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: sm.host}); err != nil {
+			return err
+		}
+	}
+
+	if sm.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("server doesn't support authentication")
+		}
+		if err := client.Auth(sm.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(sm.from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(messageBytes); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (sm *SmtpMailer) compose(message *Message) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+
+	headers := []struct{ name, value string }{
+		{"From", sm.from},
+		{"To", strings.Join(message.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{
+			"Content-Type",
+			"multipart/mixed; boundary=\"" + writer.Boundary() + "\"",
+		},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(buffer, "%v: %v\r\n", header.name, header.value)
+	}
+	buffer.WriteString("\r\n")
+
+	bodyPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bodyWriter := quotedprintable.NewWriter(bodyPart)
+	if _, err := bodyWriter.Write([]byte(message.Body)); err != nil {
+		return nil, err
+	}
+	if err := bodyWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range message.Attachments {
+		contentType := mime.TypeByExtension(filepath.Ext(attachment.Name))
+		if len(contentType) == 0 {
+			contentType = "application/octet-stream"
+		}
+
+		attachmentPart, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition": {
+				mime.FormatMediaType(
+					"attachment",
+					map[string]string{"filename": attachment.Name},
+				),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeBase64Lines(attachmentPart, attachment.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// writeBase64Lines writes the content base64 encoded in lines of 76
+// characters, as required by RFC 2045.
+func writeBase64Lines(writer io.Writer, content []byte) error {
+	const lineLength = 76
+
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 0 {
+		length := lineLength
+		if len(encoded) < length {
+			length = len(encoded)
+		}
+
+		if _, err := writer.Write([]byte(encoded[:length] + "\r\n")); err != nil {
+			return err
+		}
+
+		encoded = encoded[length:]
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// runSmtpServer starts a minimal SMTP server accepting a single message
+// without authentication and sending it to the returned channel.
+func runSmtpServer(t *testing.T) (string, int, <-chan *receivedMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan *receivedMail, 1)
+
+	go func() {
+		defer listener.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) {
+			conn.Write([]byte(line + "\r\n"))
+		}
+
+		delivered := &receivedMail{}
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimRight(line, "\r\n")
+
+			switch {
+			case strings.HasPrefix(command, "EHLO"),
+				strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				delivered.from = strings.Trim(command[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				delivered.to = append(
+					delivered.to,
+					strings.Trim(command[len("RCPT TO:"):], "<>"),
+				)
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 end data with <CR><LF>.<CR><LF>")
+
+				data := &strings.Builder{}
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				delivered.data = data.String()
+
+				reply("250 OK")
+				received <- delivered
+			case command == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port, received
+}
+
+func TestSmtpMailerSend(t *testing.T) {
+	host, port, received := runSmtpServer(t)
+
+	mailer := NewSmtpMailer(host, port, "", "", "billing@provider.com")
+
+	attachment := []byte(strings.Repeat("%PDF-1.4 billing content ", 20))
+
+	err := mailer.Send(context.Background(), &Message{
+		To:      []string{"customer@example.com"},
+		Subject: "Billing for blocks 100-200",
+		Body:    "Please find the billing attached.",
+		Attachments: []*Attachment{
+			{Name: "Customer_A_Beacon_Billing.pdf", Content: attachment},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailReceived := <-received
+
+	if mailReceived.from != "billing@provider.com" {
+		t.Errorf(
+			"unexpected sender\nexpected: [billing@provider.com]\n"+
+				"actual:   [%v]",
+			mailReceived.from,
+		)
+	}
+
+	if !reflect.DeepEqual(mailReceived.to, []string{"customer@example.com"}) {
+		t.Errorf(
+			"unexpected recipients\nexpected: [[customer@example.com]]\n"+
+				"actual:   [%v]",
+			mailReceived.to,
+		)
+	}
+
+	message, err := mail.ReadMessage(strings.NewReader(mailReceived.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if subject := message.Header.Get("Subject"); subject != "Billing for blocks 100-200" {
+		t.Errorf(
+			"unexpected subject\nexpected: [Billing for blocks 100-200]\n"+
+				"actual:   [%v]",
+			subject,
+		)
+	}
+
+	_, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := multipart.NewReader(message.Body, params["boundary"])
+
+	// multipart reader decodes quoted-printable parts transparently
+	bodyPart, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(bodyPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "Please find the billing attached." {
+		t.Errorf(
+			"unexpected body\nexpected: [Please find the billing attached.]\n"+
+				"actual:   [%v]",
+			string(body),
+		)
+	}
+
+	attachmentPart, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachmentPart.FileName() != "Customer_A_Beacon_Billing.pdf" {
+		t.Errorf(
+			"unexpected attachment name\n"+
+				"expected: [Customer_A_Beacon_Billing.pdf]\nactual:   [%v]",
+			attachmentPart.FileName(),
+		)
+	}
+
+	encoded, err := ioutil.ReadAll(attachmentPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(
+		strings.ReplaceAll(string(encoded), "\r\n", ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != string(attachment) {
+		t.Errorf(
+			"unexpected attachment content\nexpected: [%v] bytes\n"+
+				"actual:   [%v] bytes",
+			len(attachment),
+			len(decoded),
+		)
+	}
+}
+
+func TestSmtpMailerSendStopsOnCancellation(t *testing.T) {
+	// the server accepts connections but never greets the client
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ioutil.ReadAll(conn)
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+	mailer := NewSmtpMailer(
+		address.IP.String(),
+		address.Port,
+		"",
+		"",
+		"billing@provider.com",
+	)
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		100*time.Millisecond,
+	)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mailer.Send(ctx, &Message{
+			To:      []string{"customer@example.com"},
+			Subject: "Billing",
+			Body:    "Billing",
+		})
+	}()
+
+	select {
+	case err := <-done:
+		// depending on timing, the connection deadline or the context
+		// stops the session first
+		if err == nil {
+			t.Error("expected error of the stopped session")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sending was not stopped by the context")
+	}
+}
@@ -0,0 +1,272 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+	"github.com/boar-network/keep-billings/pkg/chain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ethDecimals and keepDecimals are the decimals of ETH and the KEEP token.
+const (
+	ethDecimals  = 18
+	keepDecimals = 18
+)
+
+// Payout is the transfer of the customer's share of rewards in one token
+// from the beneficiary to the customer.
+type Payout struct {
+	Customer string
+	From     string
+	To       string
+	Symbol   string
+	// token contract, empty for ETH
+	Token string
+	// whole tokens, as presented in the billing
+	Amount string
+	// amount in the smallest token denomination
+	BaseUnits *big.Int
+}
+
+// Description presents the payout in logs and generated files.
+func (p *Payout) Description() string {
+	return fmt.Sprintf(
+		"%v %v share of %v to %v",
+		p.Amount,
+		p.Symbol,
+		p.Customer,
+		p.To,
+	)
+}
+
+// NewPayouts returns the ETH and KEEP payouts of the customer's shares
+// determined by the billing. Only shares of rewards received by the
+// beneficiary during the period are paid out; accumulated ETH rewards are
+// not held by the beneficiary until withdrawn. Shares are paid out from the
+// beneficiary to the recipient. Tokens with no customer's share are
+// skipped.
+func NewPayouts(
+	report *billing.BeaconReport,
+	recipient string,
+	keepToken string,
+) ([]*Payout, error) {
+	if !common.IsHexAddress(recipient) {
+		return nil, fmt.Errorf("invalid recipient address [%v]", recipient)
+	}
+
+	if !common.IsHexAddress(report.Customer.Beneficiary) {
+		return nil, fmt.Errorf(
+			"invalid beneficiary address [%v]",
+			report.Customer.Beneficiary,
+		)
+	}
+
+	if len(keepToken) > 0 {
+		keepToken = common.HexToAddress(keepToken).Hex()
+	}
+
+	shares := []struct {
+		symbol   string
+		token    string
+		amount   string
+		decimals uint8
+	}{
+		{"ETH", "", report.CustomerReceivedEthShare, ethDecimals},
+		{"KEEP", keepToken, report.CustomerKeepShare, keepDecimals},
+	}
+
+	payouts := make([]*Payout, 0)
+
+	for _, share := range shares {
+		baseUnits, err := chain.FromTokenUnits(share.amount, share.decimals)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse %v share: [%v]",
+				share.symbol,
+				err,
+			)
+		}
+
+		if baseUnits.Sign() <= 0 {
+			continue
+		}
+
+		payouts = append(payouts, &Payout{
+			Customer:  report.Customer.Name,
+			From:      common.HexToAddress(report.Customer.Beneficiary).Hex(),
+			To:        common.HexToAddress(recipient).Hex(),
+			Symbol:    share.symbol,
+			Token:     share.token,
+			Amount:    share.amount,
+			BaseUnits: baseUnits,
+		})
+	}
+
+	return payouts, nil
+}
+
+// call returns the address called, the ETH value and the call data of the
+// payout transaction.
+func (p *Payout) call() (string, *big.Int, []byte) {
+	if len(p.Token) == 0 {
+		return p.To, p.BaseUnits, nil
+	}
+
+	return p.Token, big.NewInt(0), chain.TransferData(p.To, p.BaseUnits)
+}
+
+// Total is the sum of payouts of one token made by one sender.
+type Total struct {
+	From      string
+	Symbol    string
+	Token     string
+	BaseUnits *big.Int
+}
+
+// Totals sums up payouts by sender and token, in order of appearance.
+func Totals(payouts []*Payout) []*Total {
+	totals := make([]*Total, 0)
+	index := make(map[string]*Total)
+
+	for _, payout := range payouts {
+		key := payout.From + "/" + payout.Symbol
+
+		total, ok := index[key]
+		if !ok {
+			total = &Total{
+				From:      payout.From,
+				Symbol:    payout.Symbol,
+				Token:     payout.Token,
+				BaseUnits: big.NewInt(0),
+			}
+			index[key] = total
+			totals = append(totals, total)
+		}
+
+		total.BaseUnits = new(big.Int).Add(total.BaseUnits, payout.BaseUnits)
+	}
+
+	return totals
+}
+
+// Node plans transactions against the current state of the chain.
+type Node interface {
+	PendingNonce(ctx context.Context, address string) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(
+		ctx context.Context,
+		from string,
+		to string,
+		value *big.Int,
+		data []byte,
+	) (uint64, error)
+}
+
+// Transaction is an unsigned payout transaction. Fields follow the
+// eth_sendTransaction parameters so the transaction can be signed with
+// common wallets and tools.
+type Transaction struct {
+	Description string         `json:"description"`
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+	Data        hexutil.Bytes  `json:"data"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Gas         hexutil.Uint64 `json:"gas"`
+	GasPrice    *hexutil.Big   `json:"gasPrice"`
+	ChainID     *hexutil.Big   `json:"chainId"`
+	// set once the transaction is broadcast
+	TxHash string `json:"txHash,omitempty"`
+}
+
+// Fee returns the maximum fee of the transaction in wei.
+func (t *Transaction) Fee() *big.Int {
+	return new(big.Int).Mul(
+		new(big.Int).SetUint64(uint64(t.Gas)),
+		t.GasPrice.ToInt(),
+	)
+}
+
+// Unsigned returns the transaction ready to be signed.
+func (t *Transaction) Unsigned() *types.Transaction {
+	return types.NewTransaction(
+		uint64(t.Nonce),
+		common.HexToAddress(t.To),
+		t.Value.ToInt(),
+		uint64(t.Gas),
+		t.GasPrice.ToInt(),
+		t.Data,
+	)
+}
+
+// PlanTransactions turns payouts into transactions. Nonces of each sender
+// follow its pending nonce in order of payouts and the gas limit of each
+// transaction is estimated against the current state. The gas price
+// suggested by the node is used if no gas price is given.
+func PlanTransactions(
+	ctx context.Context,
+	node Node,
+	payouts []*Payout,
+	chainID *big.Int,
+	gasPrice *big.Int,
+) ([]*Transaction, error) {
+	if gasPrice == nil {
+		suggestedGasPrice, err := node.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not get gas price: [%v]", err)
+		}
+		gasPrice = suggestedGasPrice
+	}
+
+	nonces := make(map[string]uint64)
+	transactions := make([]*Transaction, 0, len(payouts))
+
+	for _, payout := range payouts {
+		sender := strings.ToLower(payout.From)
+
+		nonce, ok := nonces[sender]
+		if !ok {
+			pendingNonce, err := node.PendingNonce(ctx, payout.From)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"could not get nonce of [%v]: [%v]",
+					payout.From,
+					err,
+				)
+			}
+			nonce = pendingNonce
+		}
+		nonces[sender] = nonce + 1
+
+		to, value, data := payout.call()
+
+		gas, err := node.EstimateGas(ctx, payout.From, to, value, data)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not estimate gas of payout [%v]: [%v]",
+				payout.Description(),
+				err,
+			)
+		}
+
+		transactions = append(transactions, &Transaction{
+			Description: payout.Description(),
+			From:        payout.From,
+			To:          to,
+			Value:       (*hexutil.Big)(value),
+			Data:        data,
+			Nonce:       hexutil.Uint64(nonce),
+			Gas:         hexutil.Uint64(gas),
+			GasPrice:    (*hexutil.Big)(gasPrice),
+			ChainID:     (*hexutil.Big)(chainID),
+		})
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,201 @@
+package payout
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/boar-network/keep-billings/pkg/billing"
+)
+
+const (
+	beneficiary1 = "0x0000000000000000000000000000000000000011"
+	beneficiary2 = "0x0000000000000000000000000000000000000012"
+	recipient    = "0x0000000000000000000000000000000000000021"
+	keepToken    = "0x0000000000000000000000000000000000000031"
+)
+
+// localNode estimates 21000 gas for ETH transfers and 50000 gas for calls.
+type localNode struct {
+	nonces map[string]uint64
+}
+
+func (ln *localNode) PendingNonce(
+	ctx context.Context,
+	address string,
+) (uint64, error) {
+	return ln.nonces[address], nil
+}
+
+func (ln *localNode) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(20000000000), nil
+}
+
+func (ln *localNode) EstimateGas(
+	ctx context.Context,
+	from string,
+	to string,
+	value *big.Int,
+	data []byte,
+) (uint64, error) {
+	if len(data) == 0 {
+		return 21000, nil
+	}
+	return 50000, nil
+}
+
+func newReport(
+	beneficiary string,
+	ethShare string,
+	keepShare string,
+) *billing.BeaconReport {
+	return &billing.BeaconReport{
+		Report: &billing.Report{
+			Customer: &billing.Customer{
+				Name:        "Customer " + beneficiary[len(beneficiary)-2:],
+				Beneficiary: beneficiary,
+			},
+			// accumulated rewards are never paid out
+			CustomerEthShare:         "1000.000000",
+			CustomerReceivedEthShare: ethShare,
+			CustomerKeepShare:        keepShare,
+		},
+	}
+}
+
+func newTestPayouts(t *testing.T) []*Payout {
+	payouts := make([]*Payout, 0)
+
+	for _, report := range []*billing.BeaconReport{
+		newReport(beneficiary1, "1.500000", "100.000000"),
+		newReport(beneficiary2, "0.000000", "20.500000"),
+		newReport(beneficiary1, "0.250000", "0.000000"),
+	} {
+		reportPayouts, err := NewPayouts(report, recipient, keepToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payouts = append(payouts, reportPayouts...)
+	}
+
+	return payouts
+}
+
+func TestPlanTransactions(t *testing.T) {
+	payouts := newTestPayouts(t)
+
+	transactions, err := PlanTransactions(
+		context.Background(),
+		&localNode{nonces: map[string]uint64{beneficiary1: 7}},
+		payouts,
+		big.NewInt(1),
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type plannedTransaction struct {
+		from  string
+		to    string
+		value string
+		nonce uint64
+		gas   uint64
+	}
+
+	actual := make([]plannedTransaction, len(transactions))
+	for i, transaction := range transactions {
+		actual[i] = plannedTransaction{
+			from:  transaction.From,
+			to:    transaction.To,
+			value: transaction.Value.ToInt().String(),
+			nonce: uint64(transaction.Nonce),
+			gas:   uint64(transaction.Gas),
+		}
+	}
+
+	expected := []plannedTransaction{
+		{beneficiary1, recipient, "1500000000000000000", 7, 21000},
+		{beneficiary1, keepToken, "0", 8, 50000},
+		{beneficiary2, keepToken, "0", 0, 50000},
+		{beneficiary1, recipient, "250000000000000000", 9, 21000},
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf(
+			"unexpected transactions\nexpected: [%+v]\nactual:   [%+v]",
+			expected,
+			actual,
+		)
+	}
+
+	if fee := transactions[1].Fee().String(); fee != "1000000000000000" {
+		t.Errorf(
+			"unexpected fee\nexpected: [1000000000000000]\nactual:   [%v]",
+			fee,
+		)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	totals := Totals(newTestPayouts(t))
+
+	actual := make([]string, len(totals))
+	for i, total := range totals {
+		actual[i] = total.From + " " + total.Symbol + " " + total.BaseUnits.String()
+	}
+
+	expected := []string{
+		beneficiary1 + " ETH 1750000000000000000",
+		beneficiary1 + " KEEP 100000000000000000000",
+		beneficiary2 + " KEEP 20500000000000000000",
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf(
+			"unexpected totals\nexpected: [%v]\nactual:   [%v]",
+			expected,
+			actual,
+		)
+	}
+}
+
+func TestNewSafeBatches(t *testing.T) {
+	batches := NewSafeBatches(
+		newTestPayouts(t),
+		big.NewInt(1),
+		"Payouts",
+		time.Unix(1767225600, 0),
+	)
+
+	if len(batches) != 2 {
+		t.Fatalf(
+			"unexpected batches count\nexpected: [2]\nactual:   [%v]",
+			len(batches),
+		)
+	}
+
+	content, err := json.Marshal(batches[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedContent := `{"version":"1.0","chainId":"1","createdAt":1767225600000,` +
+		`"meta":{"name":"Payouts","description":"1 payouts of customers' shares",` +
+		`"createdFromSafeAddress":"` + beneficiary2 + `"},` +
+		`"transactions":[{"to":"` + keepToken + `","value":"0",` +
+		`"data":"0xa9059cbb` +
+		`0000000000000000000000000000000000000000000000000000000000000021` +
+		`0000000000000000000000000000000000000000000000011c7ea162e7820000"}]}`
+
+	if string(content) != expectedContent {
+		t.Errorf(
+			"unexpected batch\nexpected: [%v]\nactual:   [%v]",
+			expectedContent,
+			string(content),
+		)
+	}
+}
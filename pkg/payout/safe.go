@@ -0,0 +1,84 @@
+package payout
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// safeBatchVersion is the version of the Safe Transaction Builder batch
+// file format.
+const safeBatchVersion = "1.0"
+
+// SafeBatch is a batch of payouts made by a Gnosis Safe, in the format
+// imported by the Safe Transaction Builder app. All transactions of the
+// batch are executed in a single Safe transaction.
+type SafeBatch struct {
+	Version      string             `json:"version"`
+	ChainID      string             `json:"chainId"`
+	CreatedAt    int64              `json:"createdAt"`
+	Meta         *SafeBatchMeta     `json:"meta"`
+	Transactions []*SafeTransaction `json:"transactions"`
+}
+
+type SafeBatchMeta struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	CreatedFromSafeAddress string `json:"createdFromSafeAddress"`
+}
+
+type SafeTransaction struct {
+	To string `json:"to"`
+	// ETH value in wei
+	Value string        `json:"value"`
+	Data  hexutil.Bytes `json:"data"`
+}
+
+// NewSafeBatches groups payouts into batches, one for each beneficiary
+// Safe, in order of appearance.
+func NewSafeBatches(
+	payouts []*Payout,
+	chainID *big.Int,
+	name string,
+	createdAt time.Time,
+) []*SafeBatch {
+	batches := make([]*SafeBatch, 0)
+	index := make(map[string]*SafeBatch)
+
+	for _, payout := range payouts {
+		safe := strings.ToLower(payout.From)
+
+		batch, ok := index[safe]
+		if !ok {
+			batch = &SafeBatch{
+				Version:   safeBatchVersion,
+				ChainID:   chainID.String(),
+				CreatedAt: createdAt.UnixNano() / int64(time.Millisecond),
+				Meta: &SafeBatchMeta{
+					Name:                   name,
+					CreatedFromSafeAddress: payout.From,
+				},
+				Transactions: make([]*SafeTransaction, 0),
+			}
+			index[safe] = batch
+			batches = append(batches, batch)
+		}
+
+		to, value, data := payout.call()
+
+		batch.Transactions = append(batch.Transactions, &SafeTransaction{
+			To:    to,
+			Value: value.String(),
+			Data:  data,
+		})
+		batch.Meta.Description = fmt.Sprintf(
+			"%v payouts of customers' shares",
+			len(batch.Transactions),
+		)
+	}
+
+	return batches
+}
@@ -0,0 +1,168 @@
+// Package price provides historical token prices in fiat currencies used to
+// value rewards in annual statements.
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCoinGeckoURL is the CoinGecko API used if no other URL is set.
+const DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3"
+
+// DefaultCoinIDs maps token symbols to CoinGecko coin IDs. IDs of other
+// tokens have to be configured.
+var DefaultCoinIDs = map[string]string{
+	"ETH":  "ethereum",
+	"KEEP": "keep-network",
+}
+
+// CoinGecko reads daily prices from the CoinGecko coin history API. The
+// price of a day is the price at 00:00 UTC of that day. Prices are cached,
+// so each day and token is requested once.
+type CoinGecko struct {
+	apiURL   string
+	currency string
+	coinIDs  map[string]string
+	client   *http.Client
+
+	cacheMutex sync.Mutex
+	cache      map[string]*big.Float
+}
+
+// NewCoinGecko creates the price source of the given currency, for example
+// usd or eur. The given coin IDs complement or override DefaultCoinIDs.
+func NewCoinGecko(
+	apiURL string,
+	currency string,
+	coinIDs map[string]string,
+) *CoinGecko {
+	if len(apiURL) == 0 {
+		apiURL = DefaultCoinGeckoURL
+	}
+
+	ids := make(map[string]string)
+	for symbol, id := range DefaultCoinIDs {
+		ids[symbol] = id
+	}
+	for symbol, id := range coinIDs {
+		ids[strings.ToUpper(symbol)] = id
+	}
+
+	return &CoinGecko{
+		apiURL:   strings.TrimSuffix(apiURL, "/"),
+		currency: strings.ToLower(currency),
+		coinIDs:  ids,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		cache:    make(map[string]*big.Float),
+	}
+}
+
+func (cg *CoinGecko) Price(
+	ctx context.Context,
+	symbol string,
+	at time.Time,
+) (*big.Float, error) {
+	coinID, ok := cg.coinIDs[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, fmt.Errorf("no CoinGecko coin ID of token [%v]", symbol)
+	}
+
+	date := at.UTC().Format("02-01-2006")
+	key := coinID + "|" + date
+
+	cg.cacheMutex.Lock()
+	price, ok := cg.cache[key]
+	cg.cacheMutex.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	price, err := cg.fetchPrice(ctx, coinID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	cg.cacheMutex.Lock()
+	cg.cache[key] = price
+	cg.cacheMutex.Unlock()
+
+	return price, nil
+}
+
+func (cg *CoinGecko) fetchPrice(
+	ctx context.Context,
+	coinID string,
+	date string,
+) (*big.Float, error) {
+	query := url.Values{}
+	query.Set("date", date)
+	query.Set("localization", "false")
+
+	request, err := http.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf(
+			"%v/coins/%v/history?%v",
+			cg.apiURL,
+			url.PathEscape(coinID),
+			query.Encode(),
+		),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := cg.client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not request CoinGecko: [%v]", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"CoinGecko responded with status [%v]",
+			response.Status,
+		)
+	}
+
+	var history struct {
+		MarketData *struct {
+			CurrentPrice map[string]json.Number `json:"current_price"`
+		} `json:"market_data"`
+	}
+
+	decoder := json.NewDecoder(response.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&history); err != nil {
+		return nil, fmt.Errorf("could not decode CoinGecko response: [%v]", err)
+	}
+
+	if history.MarketData == nil {
+		return nil, fmt.Errorf("no market data of [%v] on [%v]", coinID, date)
+	}
+
+	value, ok := history.MarketData.CurrentPrice[cg.currency]
+	if !ok {
+		return nil, fmt.Errorf(
+			"no [%v] price of [%v] on [%v]",
+			cg.currency,
+			coinID,
+			date,
+		)
+	}
+
+	price, ok := new(big.Float).SetString(value.String())
+	if !ok {
+		return nil, fmt.Errorf("could not parse price [%v]", value)
+	}
+
+	return price, nil
+}
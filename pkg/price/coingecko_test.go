@@ -0,0 +1,122 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCoinGeckoServer starts a server answering coin history requests with
+// the given USD prices by coin ID and date. Returns the number of requests
+// served along with the server.
+func newCoinGeckoServer(prices map[string]string) (*httptest.Server, *int) {
+	requests := 0
+
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			coinID := strings.TrimSuffix(
+				strings.TrimPrefix(r.URL.Path, "/coins/"),
+				"/history",
+			)
+
+			price, ok := prices[coinID+"|"+r.URL.Query().Get("date")]
+			if !ok {
+				fmt.Fprint(w, `{"id":"unknown"}`)
+				return
+			}
+
+			fmt.Fprintf(
+				w,
+				`{"market_data":{"current_price":{"eur":1,"usd":%v}}}`,
+				price,
+			)
+		},
+	)), &requests
+}
+
+func TestCoinGeckoPrice(t *testing.T) {
+	server, requests := newCoinGeckoServer(map[string]string{
+		"ethereum|31-01-2026":     "2512.4",
+		"keep-network|31-01-2026": "0.1234",
+		"tbtc|31-01-2026":         "101000",
+	})
+	defer server.Close()
+
+	coinGecko := NewCoinGecko(
+		server.URL,
+		"USD",
+		map[string]string{"tbtc": "tbtc"},
+	)
+
+	at := time.Date(2026, 1, 31, 17, 30, 0, 0, time.UTC)
+
+	var tests = map[string]struct {
+		symbol        string
+		at            time.Time
+		expectedPrice string
+		expectedError bool
+	}{
+		"default coin ID": {
+			symbol:        "ETH",
+			at:            at,
+			expectedPrice: "2512.4",
+		},
+		"lower case symbol": {
+			symbol:        "keep",
+			at:            at,
+			expectedPrice: "0.1234",
+		},
+		"configured coin ID": {
+			symbol:        "TBTC",
+			at:            at,
+			expectedPrice: "101000",
+		},
+		"unknown token": {
+			symbol:        "XYZ",
+			at:            at,
+			expectedError: true,
+		},
+		"no market data": {
+			symbol:        "ETH",
+			at:            at.AddDate(-10, 0, 0),
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			price, err := coinGecko.Price(context.Background(), test.symbol, test.at)
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("expected error, got price [%v]", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if price.Text('f', -1) != test.expectedPrice {
+				t.Errorf(
+					"unexpected price\nexpected: [%v]\nactual:   [%v]",
+					test.expectedPrice,
+					price.Text('f', -1),
+				)
+			}
+		})
+	}
+
+	before := *requests
+	if _, err := coinGecko.Price(context.Background(), "ETH", at); err != nil {
+		t.Fatal(err)
+	}
+	if *requests != before {
+		t.Errorf("cached price requested again")
+	}
+}
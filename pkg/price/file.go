@@ -0,0 +1,86 @@
+package price
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// fileDateLayout is the layout of dates in price files.
+const fileDateLayout = "2006-01-02"
+
+// File serves daily prices read from a CSV file with date, token symbol
+// and price records, for example `2026-01-31,ETH,2512.40`. Dates are in
+// UTC. Lines starting with # are comments.
+type File struct {
+	prices map[string]*big.Float
+}
+
+// NewFile reads all prices from the file.
+func NewFile(fileName string) (*File, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not read price file: [%v]", err)
+	}
+
+	prices := make(map[string]*big.Float)
+	for i, record := range records {
+		date, err := time.Parse(fileDateLayout, record[0])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not parse date of price [%v]: [%v]",
+				i+1,
+				err,
+			)
+		}
+
+		price, ok := new(big.Float).SetString(record[2])
+		if !ok {
+			return nil, fmt.Errorf(
+				"could not parse price [%v]: [%v]",
+				i+1,
+				record[2],
+			)
+		}
+
+		prices[priceKey(record[1], date)] = price
+	}
+
+	return &File{prices}, nil
+}
+
+func (f *File) Price(
+	ctx context.Context,
+	symbol string,
+	at time.Time,
+) (*big.Float, error) {
+	price, ok := f.prices[priceKey(symbol, at)]
+	if !ok {
+		return nil, fmt.Errorf(
+			"no price of [%v] on [%v] in the price file",
+			symbol,
+			at.UTC().Format(fileDateLayout),
+		)
+	}
+
+	return price, nil
+}
+
+func priceKey(symbol string, at time.Time) string {
+	return strings.ToUpper(symbol) + "|" + at.UTC().Format(fileDateLayout)
+}
@@ -0,0 +1,78 @@
+package price
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFilePrice(t *testing.T) {
+	file, err := ioutil.TempFile("", "prices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(
+		"# date, token, price in EUR\n" +
+			"2026-01-31,ETH,2512.40\n" +
+			"2026-01-31, KEEP, 0.1234\n",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	prices, err := NewFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		symbol        string
+		at            time.Time
+		expectedPrice string
+		expectedError bool
+	}{
+		"price of the day": {
+			symbol:        "ETH",
+			at:            time.Date(2026, 1, 31, 23, 59, 0, 0, time.UTC),
+			expectedPrice: "2512.4",
+		},
+		"time in other zone": {
+			symbol:        "keep",
+			at:            time.Date(2026, 2, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600)),
+			expectedPrice: "0.1234",
+		},
+		"missing day": {
+			symbol:        "ETH",
+			at:            time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			expectedError: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			price, err := prices.Price(context.Background(), test.symbol, test.at)
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("expected error, got price [%v]", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if price.Text('f', -1) != test.expectedPrice {
+				t.Errorf(
+					"unexpected price\nexpected: [%v]\nactual:   [%v]",
+					test.expectedPrice,
+					price.Text('f', -1),
+				)
+			}
+		})
+	}
+}
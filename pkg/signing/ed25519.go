@@ -0,0 +1,113 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SignatureFileSuffix is appended to the name of the signed file to get
+// the name of its detached signature file.
+const SignatureFileSuffix = ".sig"
+
+// Signer creates detached Ed25519 signatures. A signature file contains
+// the base64 encoded signature of the whole signed file.
+type Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewSigner reads the Ed25519 private key from a PEM file in the PKCS #8
+// format, as generated by `openssl genpkey -algorithm ed25519`.
+func NewSigner(keyFile string) (*Signer, error) {
+	block, err := readPemBlock(keyFile, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key: [%v]", err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type: [%T]", key)
+	}
+
+	return &Signer{privateKey}, nil
+}
+
+// Sign returns the content of the detached signature file of the data.
+func (s *Signer) Sign(data []byte) []byte {
+	signature := ed25519.Sign(s.privateKey, data)
+	return []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+}
+
+// PublicKey returns the public key verifying signatures of the signer.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// ReadPublicKey reads the Ed25519 public key from a PEM file in the PKIX
+// format, as generated by `openssl pkey -pubout`.
+func ReadPublicKey(keyFile string) (ed25519.PublicKey, error) {
+	block, err := readPemBlock(keyFile, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse public key: [%v]", err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type: [%T]", key)
+	}
+
+	return publicKey, nil
+}
+
+// Verify checks the detached signature file content against the data.
+func Verify(
+	publicKey ed25519.PublicKey,
+	data []byte,
+	signatureFile []byte,
+) error {
+	signature, err := base64.StdEncoding.DecodeString(
+		strings.TrimSpace(string(signatureFile)),
+	)
+	if err != nil {
+		return fmt.Errorf("could not decode signature: [%v]", err)
+	}
+
+	if len(signature) != ed25519.SignatureSize ||
+		!ed25519.Verify(publicKey, data, signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func readPemBlock(file string, blockType string) (*pem.Block, error) {
+	fileBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(fileBytes)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf(
+			"could not find [%v] PEM block in [%v]",
+			blockType,
+			file,
+		)
+	}
+
+	return block, nil
+}
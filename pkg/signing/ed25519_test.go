@@ -0,0 +1,113 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeys(t *testing.T) (string, string) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory, err := ioutil.TempDir("", "signing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKeyFile := filepath.Join(directory, "private.pem")
+	publicKeyFile := filepath.Join(directory, "public.pem")
+
+	err = ioutil.WriteFile(
+		privateKeyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}),
+		0600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(
+		publicKeyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}),
+		0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKeyFile, publicKeyFile
+}
+
+func TestSignAndVerify(t *testing.T) {
+	privateKeyFile, publicKeyFile := writeKeys(t)
+	defer os.RemoveAll(filepath.Dir(privateKeyFile))
+
+	signer, err := NewSigner(privateKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := ReadPublicKey(publicKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"Stake":"100000"}`)
+	signature := signer.Sign(data)
+
+	var tests = map[string]struct {
+		data          []byte
+		signature     []byte
+		expectedValid bool
+	}{
+		"original data": {
+			data:          data,
+			signature:     signature,
+			expectedValid: true,
+		},
+		"altered data": {
+			data:          []byte(`{"Stake":"900000"}`),
+			signature:     signature,
+			expectedValid: false,
+		},
+		"malformed signature": {
+			data:          data,
+			signature:     []byte("not a signature"),
+			expectedValid: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := Verify(publicKey, test.data, test.signature)
+
+			if valid := err == nil; valid != test.expectedValid {
+				t.Errorf(
+					"unexpected verification result\nexpected: [%v]\n"+
+						"actual:   [%v]\nerror: [%v]",
+					test.expectedValid,
+					valid,
+					err,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package signing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EthereumSigner signs messages with an Ethereum account key following
+// EIP-191 (personal_sign), so signatures can be checked with common
+// wallets and tools.
+type EthereumSigner struct {
+	key *keystore.Key
+}
+
+// NewEthereumSigner decrypts the account key from the keystore file.
+func NewEthereumSigner(keyFile string, password string) (*EthereumSigner, error) {
+	keyJson, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(keyJson, password)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt key file: [%v]", err)
+	}
+
+	return &EthereumSigner{key}, nil
+}
+
+func (es *EthereumSigner) Address() common.Address {
+	return es.key.Address
+}
+
+// SignMessage returns the 65 bytes long signature of the message. The
+// recovery ID is 27 or 28, as returned by wallets.
+func (es *EthereumSigner) SignMessage(message []byte) ([]byte, error) {
+	signature, err := crypto.Sign(accounts.TextHash(message), es.key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return signature, nil
+}
+
+// SignTransaction signs the transaction for the given chain following
+// EIP-155.
+func (es *EthereumSigner) SignTransaction(
+	tx *types.Transaction,
+	chainID *big.Int,
+) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), es.key.PrivateKey)
+}
+
+// RecoverMessageSigner returns the address of the account which signed
+// the message following EIP-191. Both 0/1 and 27/28 recovery IDs are
+// accepted.
+func RecoverMessageSigner(
+	message []byte,
+	signature []byte,
+) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf(
+			"signature has [%v] bytes instead of [%v]",
+			len(signature),
+			crypto.SignatureLength,
+		)
+	}
+
+	normalized := make([]byte, len(signature))
+	copy(normalized, signature)
+	if normalized[crypto.RecoveryIDOffset] >= 27 {
+		normalized[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(accounts.TextHash(message), normalized)
+	if err != nil {
+		return common.Address{}, fmt.Errorf(
+			"could not recover signer: [%v]",
+			err,
+		)
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
@@ -0,0 +1,77 @@
+package signing
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSignAndRecoverMessageSigner(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory, err := ioutil.TempDir("", "signing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	account, err := keystore.NewKeyStore(
+		directory,
+		keystore.LightScryptN,
+		keystore.LightScryptP,
+	).ImportECDSA(privateKey, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewEthereumSigner(account.URL.Path, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("Customer ETH share: 1.5")
+
+	signature, err := signer.SignMessage(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]struct {
+		message       []byte
+		expectedValid bool
+	}{
+		"original message": {
+			message:       message,
+			expectedValid: true,
+		},
+		"altered message": {
+			message:       []byte("Customer ETH share: 9.5"),
+			expectedValid: false,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			recovered, err := RecoverMessageSigner(test.message, signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if valid := recovered == account.Address; valid != test.expectedValid {
+				t.Errorf(
+					"unexpected signer\nexpected match: [%v]\n"+
+						"recovered: [%v]\nsigner:    [%v]",
+					test.expectedValid,
+					recovered.Hex(),
+					account.Address.Hex(),
+				)
+			}
+		})
+	}
+}
@@ -65,6 +65,7 @@ printf "${DONE_START}Temporary data have been cleaned up successfully!${DONE_END
 
 printf "${LOG_START}Building the binary...${LOG_END}"
 
+go generate ./pkg/assets
 go build
 
 printf "${DONE_START}Binary has been built successfully!${DONE_END}"
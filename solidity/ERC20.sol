@@ -1,5 +1,11 @@
 pragma solidity 0.5.17;
 
 contract ERC20 {
+    event Transfer(address indexed from, address indexed to, uint tokens);
+
+    function symbol() public view returns (string memory);
+
+    function decimals() public view returns (uint8);
+
     function balanceOf(address tokenOwner) public view returns (uint balance);
-}
\ No newline at end of file
+}